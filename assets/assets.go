@@ -0,0 +1,50 @@
+// Package assets holds the font and form template the PDF is drawn with.
+// They are embedded in the binary so printing works from the executable
+// alone; a file of the same name in the override directory is used instead.
+package assets
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//go:embed JetBrainsMono-Bold.json JetBrainsMono-Bold.z form_template.png
+var embedded embed.FS
+
+// ReadFile reads name from overrideDir when it is set and has the file, or
+// else from the embedded assets.
+func ReadFile(overrideDir, name string) ([]byte, error) {
+	if overrideDir != "" {
+		data, err := os.ReadFile(filepath.Join(overrideDir, name))
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("cannot read asset %s: %w", name, err)
+		}
+	}
+
+	data, err := embedded.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("asset %s not found in %q or the embedded assets", name, overrideDir)
+	}
+
+	return data, nil
+}
+
+// Source tells where ReadFile would read name from: the file path in
+// overrideDir, or "embedded".
+func Source(overrideDir, name string) string {
+	if overrideDir != "" {
+		path := filepath.Join(overrideDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return "embedded"
+}
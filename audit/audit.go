@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bgics/pmjay-go/crypt"
+)
+
+const (
+	ActionPrint   = "print"
+	ActionSave    = "save"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionRevert  = "revert"
+	ActionEncrypt = "encrypt"
+	ActionExport  = "export"
+	ActionBackup  = "backup"
+
+	ActionRestoreBackup  = "restore-backup"
+	ActionImportPackages = "import-packages"
+	ActionImportPincodes = "import-pincodes"
+	ActionMerge          = "merge"
+
+	OutcomeOK = "ok"
+)
+
+// Entry is a single line of the audit log.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Operator    string    `json:"operator"`
+	Action      string    `json:"action"`
+	PatientID   string    `json:"patient_id"`
+	PatientName string    `json:"patient_name"`
+	Template    string    `json:"template,omitempty"`
+	Dates       []string  `json:"dates,omitempty"`
+	Printer     string    `json:"printer,omitempty"`
+	Backup      string    `json:"backup,omitempty"`
+	Outcome     string    `json:"outcome"`
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	// Patient matches the patient ID exactly or a part of the patient name.
+	Patient string
+	// From and To bound the day of the entry or of any date it printed,
+	// both inclusive.
+	From time.Time
+	To   time.Time
+}
+
+// Log is an append-only JSON Lines audit log.
+type Log struct {
+	fileStr string
+	key     *crypt.Key
+	mu      sync.Mutex
+}
+
+func NewLog(fileStr string) *Log {
+	return &Log{fileStr: fileStr}
+}
+
+// SetKey sets the key lines are encrypted with from now on.
+func (l *Log) SetKey(key *crypt.Key) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.key = key
+}
+
+// Rewrite writes every line of the log again with the current key.
+func (l *Log) Rewrite() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.key.RewriteLines(l.fileStr)
+}
+
+func (l *Log) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.fileStr, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	_, err = file.Write(append(l.key.EncodeLine(line), '\n'))
+	return err
+}
+
+// Query returns the entries matching f, newest first.
+func (l *Log) Query(f Filter, dateFormat string) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.fileStr)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	var output []Entry
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var e Entry
+		line, err := l.key.DecodeLine(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if f.matches(e, dateFormat) {
+			output = append(output, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(output)
+
+	return output, nil
+}
+
+func (f Filter) matches(e Entry, dateFormat string) bool {
+	if patient := sanitizeString(f.Patient); patient != "" {
+		if e.PatientID != strings.TrimSpace(f.Patient) && !strings.Contains(sanitizeString(e.PatientName), patient) {
+			return false
+		}
+	}
+
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+
+	if f.inRange(e.Time) {
+		return true
+	}
+	for _, dateStr := range e.Dates {
+		date, err := time.Parse(dateFormat, dateStr)
+		if err == nil && f.inRange(date) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f Filter) inRange(t time.Time) bool {
+	day := truncateDay(t)
+	if !f.From.IsZero() && day.Before(truncateDay(f.From)) {
+		return false
+	}
+	if !f.To.IsZero() && day.After(truncateDay(f.To)) {
+		return false
+	}
+	return true
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sanitizeString(str string) string {
+	return strings.TrimSpace(strings.ToLower(str))
+}
+
+// Outcome returns the outcome string recorded for err.
+func Outcome(err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return OutcomeOK
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const MinPINLength = 4
+
+var (
+	ErrInvalidLogin = errors.New("invalid name or PIN")
+	ErrUserExists   = errors.New("user already exists")
+	ErrUserNotFound = errors.New("user not found")
+	ErrLastAdmin    = errors.New("the last admin cannot be removed or demoted")
+)
+
+// User is an operator of the app. The PIN is only kept as a bcrypt hash.
+type User struct {
+	Name    string `json:"name"`
+	Role    Role   `json:"role"`
+	PINHash string `json:"pin_hash"`
+}
+
+// Users is the local user list, stored as JSON.
+type Users struct {
+	fileStr string
+	users   []User
+	isValid bool
+	mu      sync.Mutex
+}
+
+func NewUsers(fileStr string) *Users {
+	return &Users{fileStr: fileStr}
+}
+
+func (u *Users) List() ([]User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(u.users), nil
+}
+
+func (u *Users) Add(name, pin string, role Role) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if u.getUserIndex(name) != -1 {
+		return ErrUserExists
+	}
+
+	hash, err := hashPIN(pin)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+
+	u.users = append(u.users, User{Name: name, Role: role, PINHash: hash})
+
+	return u.storeUsers()
+}
+
+func (u *Users) Remove(name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return err
+	}
+
+	index := u.getUserIndex(name)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+	if u.isLastAdmin(index) {
+		return ErrLastAdmin
+	}
+
+	u.users = append(u.users[:index], u.users[index+1:]...)
+
+	return u.storeUsers()
+}
+
+func (u *Users) SetPIN(name, pin string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return err
+	}
+
+	index := u.getUserIndex(name)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+
+	hash, err := hashPIN(pin)
+	if err != nil {
+		return err
+	}
+	u.users[index].PINHash = hash
+
+	return u.storeUsers()
+}
+
+func (u *Users) SetRole(name string, role Role) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return err
+	}
+
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+
+	index := u.getUserIndex(name)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+	if role != RoleAdmin && u.isLastAdmin(index) {
+		return ErrLastAdmin
+	}
+	u.users[index].Role = role
+
+	return u.storeUsers()
+}
+
+// Authenticate returns the user with the given name if pin matches.
+func (u *Users) Authenticate(name, pin string) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.ensureLoaded(); err != nil {
+		return User{}, err
+	}
+
+	index := u.getUserIndex(name)
+	if index == -1 {
+		// compare anyway so an unknown name takes as long as a wrong PIN
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(pin))
+		return User{}, ErrInvalidLogin
+	}
+
+	user := u.users[index]
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PINHash), []byte(pin)); err != nil {
+		return User{}, ErrInvalidLogin
+	}
+
+	return user, nil
+}
+
+// ValidatePIN reports why pin cannot be used, if at all.
+func ValidatePIN(pin string) error {
+	if len(pin) < MinPINLength {
+		return fmt.Errorf("PIN must have at least %d digits", MinPINLength)
+	}
+	for _, r := range pin {
+		if !unicode.IsDigit(r) {
+			return fmt.Errorf("PIN must only contain digits")
+		}
+	}
+	return nil
+}
+
+const dummyHash = "$2a$10$s/TDjXYCXxbDMh2Y/frWX.BwAQO7hp/vThNkNpD4oi3wG206ajoyC"
+
+func hashPIN(pin string) (string, error) {
+	if err := ValidatePIN(pin); err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (u *Users) getUserIndex(name string) int {
+	for i, user := range u.users {
+		if sanitizeString(user.Name) == sanitizeString(name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (u *Users) isLastAdmin(index int) bool {
+	if u.users[index].Role != RoleAdmin {
+		return false
+	}
+	for i, user := range u.users {
+		if i != index && user.Role == RoleAdmin {
+			return false
+		}
+	}
+	return true
+}
+
+func sanitizeString(str string) string {
+	return strings.TrimSpace(strings.ToLower(str))
+}
+
+func (u *Users) ensureLoaded() error {
+	if u.isValid {
+		return nil
+	}
+	if err := u.loadUsers(); err != nil {
+		return fmt.Errorf("cannot load users: %w", err)
+	}
+	return nil
+}
+
+func (u *Users) loadUsers() error {
+	data, err := os.ReadFile(u.fileStr)
+	if os.IsNotExist(err) {
+		u.users = nil
+		u.isValid = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return err
+	}
+
+	u.users = users
+	u.isValid = true
+	return nil
+}
+
+func (u *Users) storeUsers() error {
+	data, err := json.MarshalIndent(u.users, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(u.fileStr, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		u.isValid = false
+		return fmt.Errorf("cannot save users: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	if _, err := file.Write(data); err != nil {
+		u.isValid = false
+		return fmt.Errorf("cannot save users: %w", err)
+	}
+
+	return nil
+}
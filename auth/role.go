@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleNurse  Role = "nurse"
+	RoleDoctor Role = "doctor"
+	RoleAdmin  Role = "admin"
+)
+
+var Roles = []Role{RoleNurse, RoleDoctor, RoleAdmin}
+
+type Permission string
+
+const (
+	PermSave           Permission = "save"
+	PermPrint          Permission = "print"
+	PermDelete         Permission = "delete"
+	PermEditAfterPrint Permission = "edit-after-print"
+	PermViewAudit      Permission = "view-audit"
+	PermManageData     Permission = "manage-data"
+	PermManageUsers    Permission = "manage-users"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+var rolePermissions = map[Role][]Permission{
+	RoleNurse:  {PermSave, PermPrint},
+	RoleDoctor: {PermSave, PermPrint, PermEditAfterPrint, PermViewAudit},
+	RoleAdmin:  {PermSave, PermPrint, PermEditAfterPrint, PermViewAudit, PermDelete, PermManageData, PermManageUsers},
+}
+
+func ParseRole(str string) (Role, error) {
+	role := Role(str)
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("invalid role %q, expected one of %v", str, Roles)
+	}
+	return role, nil
+}
+
+func (u User) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[u.Role], p)
+}
+
+// Require returns ErrPermissionDenied if u does not have permission p.
+func (u User) Require(p Permission) error {
+	if !u.Can(p) {
+		return fmt.Errorf("%w: %s cannot %s as %s", ErrPermissionDenied, u.Name, p, u.Role)
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ScheduleWrite = "write"
+	ScheduleDaily = "daily"
+
+	timeLayout        = "20060102-150405.000000"
+	checksumExtension = ".sha256"
+)
+
+var ErrChecksumMismatch = errors.New("backup checksum does not match, the file is damaged")
+
+// Backup is a timestamped copy of the data file with a sha256 checksum next
+// to it, in the format of sha256sum.
+type Backup struct {
+	Name string
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// Manager keeps up to Keep backups of the file at Source in Dir.
+type Manager struct {
+	Source   string
+	Dir      string
+	Keep     int
+	Schedule string
+}
+
+// BeforeWrite is called before Source is overwritten. It backs it up if the
+// schedule asks for it.
+func (m *Manager) BeforeWrite() error {
+	if _, err := os.Stat(m.Source); os.IsNotExist(err) {
+		return nil
+	}
+
+	if m.Schedule == ScheduleDaily {
+		backups, err := m.List()
+		if err != nil {
+			return err
+		}
+		if len(backups) > 0 && sameDay(backups[0].Time, time.Now()) {
+			return nil
+		}
+	}
+
+	_, err := m.Create()
+	return err
+}
+
+// Create backs up Source now and drops the oldest backups beyond Keep.
+func (m *Manager) Create() (Backup, error) {
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return Backup{}, err
+	}
+
+	now := time.Now()
+	name := m.backupName(now)
+	path := filepath.Join(m.Dir, name)
+
+	sum, size, err := copyFile(m.Source, path, os.O_EXCL)
+	for os.IsExist(err) {
+		// never overwrite a backup taken in the same instant
+		now = now.Add(time.Microsecond)
+		name = m.backupName(now)
+		path = filepath.Join(m.Dir, name)
+		sum, size, err = copyFile(m.Source, path, os.O_EXCL)
+	}
+	if err != nil {
+		return Backup{}, err
+	}
+
+	line := fmt.Sprintf("%s  %s\n", sum, name)
+	if err := os.WriteFile(path+checksumExtension, []byte(line), 0o600); err != nil {
+		return Backup{}, err
+	}
+
+	if err := m.rotate(); err != nil {
+		return Backup{}, fmt.Errorf("cannot rotate backups: %w", err)
+	}
+
+	return Backup{Name: name, Path: path, Time: now, Size: size}, nil
+}
+
+// List returns the backups in Dir, newest first.
+func (m *Manager) List() ([]Backup, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var output []Backup
+	for _, entry := range entries {
+		t, ok := m.parseBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		output = append(output, Backup{
+			Name: entry.Name(),
+			Path: filepath.Join(m.Dir, entry.Name()),
+			Time: t,
+			Size: info.Size(),
+		})
+	}
+
+	slices.SortFunc(output, func(a, b Backup) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	return output, nil
+}
+
+// Find returns the backup with the given file name.
+func (m *Manager) Find(name string) (Backup, error) {
+	backups, err := m.List()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	for _, b := range backups {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+
+	return Backup{}, fmt.Errorf("backup %q not found", name)
+}
+
+// Verify checks b against its checksum file.
+func Verify(b Backup) error {
+	data, err := os.ReadFile(b.Path + checksumExtension)
+	if err != nil {
+		return fmt.Errorf("cannot read checksum: %w", err)
+	}
+
+	want, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+
+	got, err := fileChecksum(b.Path)
+	if err != nil {
+		return err
+	}
+
+	if got != want {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// Restore verifies b, backs up the current Source and replaces it with b.
+// b is copied aside first, as backing up may rotate it away.
+func (m *Manager) Restore(b Backup) error {
+	if err := Verify(b); err != nil {
+		return err
+	}
+
+	tmpFileStr := m.Source + ".tmp"
+	if _, _, err := copyFile(b.Path, tmpFileStr, os.O_TRUNC); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(m.Source); err == nil {
+		if _, err := m.Create(); err != nil {
+			os.Remove(tmpFileStr)
+			return fmt.Errorf("cannot back up current data: %w", err)
+		}
+	}
+
+	return os.Rename(tmpFileStr, m.Source)
+}
+
+// Rewrite replaces every backup with encode of its content, e.g. to encrypt
+// it with a new key, and updates its checksum. Each backup is verified first
+// so that a damaged one is not given a fresh checksum.
+func (m *Manager) Rewrite(encode func(data []byte) []byte) error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	for _, b := range backups {
+		if err := Verify(b); err != nil {
+			return fmt.Errorf("backup %s: %w", b.Name, err)
+		}
+
+		data, err := os.ReadFile(b.Path)
+		if err != nil {
+			return err
+		}
+		data = encode(data)
+
+		tmpFileStr := b.Path + ".tmp"
+		if err := os.WriteFile(tmpFileStr, data, 0o600); err != nil {
+			os.Remove(tmpFileStr)
+			return err
+		}
+		if err := os.Rename(tmpFileStr, b.Path); err != nil {
+			os.Remove(tmpFileStr)
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), b.Name)
+		if err := os.WriteFile(b.Path+checksumExtension, []byte(line), 0o600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) rotate() error {
+	if m.Keep <= 0 {
+		return nil
+	}
+
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	for _, b := range backups[min(m.Keep, len(backups)):] {
+		if err := os.Remove(b.Path); err != nil {
+			return err
+		}
+		if err := os.Remove(b.Path + checksumExtension); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupName returns data-20060102-150405.000000.csv for a Source of
+// data.csv.
+func (m *Manager) backupName(t time.Time) string {
+	base := filepath.Base(m.Source)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + t.Format(timeLayout) + ext
+}
+
+func (m *Manager) parseBackupName(name string) (time.Time, bool) {
+	base := filepath.Base(m.Source)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// copyFile copies src to dst and returns the sha256 and size of the copy.
+// flag is os.O_EXCL to fail if dst exists or os.O_TRUNC to replace it.
+func copyFile(src, dst string, flag int) (string, int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err := in.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|flag|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), bufio.NewReader(in))
+	if err != nil {
+		out.Close()
+		return "", 0, err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return "", 0, err
+	}
+	if err := out.Close(); err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type FieldName int
 
 const (
@@ -13,23 +15,30 @@ const (
 	DAY_OF_ADMISSION
 	DATE_OF_ADMISSION
 	DIAGNOSIS
+	PACKAGES
 )
 
 const (
-	TemplateFileStr = "./assets/form_template.png"
-	FontDirStr      = "./assets"
-
 	A4WidthMM  = 210
 	A4HeightMM = 297
 
 	FieldYOffset = 0.5
 
-	ExeName    = ".\\PDFtoPrinter.exe"
-	DateFormat = "02/01/2006"
+	GenderStrLen = 3
+
+	UndoTimeout   = 8 * time.Second
+	NoticeTimeout = 6 * time.Second
 
-	OutputFileName = "output.pdf"
+	// DraftSaveDelay is how long the form waits after an edit before it
+	// saves the draft
+	DraftSaveDelay = time.Second
 
-	GenderStrLen = 3
+	// LargePrintPages is the number of pages above which a print is
+	// confirmed first
+	LargePrintPages = 5
+
+	PrintRetryDelay = 2 * time.Second
+	PrintWaitDelay  = 5 * time.Second
 )
 
 var FieldConfig = map[FieldName]struct {
@@ -47,16 +56,19 @@ var FieldConfig = map[FieldName]struct {
 	DAY_OF_ADMISSION:  {159.75, 71.56, 15},
 	DATE_OF_ADMISSION: {47.21, 80.57, 32},
 	DIAGNOSIS:         {32.93, 89.58, 70},
+	PACKAGES:          {12.43, 98.59, 80},
 }
 
 var FontConfig = struct {
 	FamilyStr string
 	StyleStr  string
 	FileStr   string
+	ZFileStr  string
 	Size      float64
 }{
 	"JetBrainsMono",
 	"",
 	"JetBrainsMono-Bold.json",
+	"JetBrainsMono-Bold.z",
 	11,
 }
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// StorageDateFormat is used for dates in the data file and the logs. It
+	// does not follow Settings.DateFormat so changing that keeps old files
+	// readable.
+	StorageDateFormat = "02/01/2006"
+
+	SettingsFileName = "pmjay.json"
+	appDirName       = "pmjay"
+)
+
+type Hospital struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+}
+
+type PrintSettings struct {
+	// Command is run with Args to print, "{file}" in Args is replaced by the
+	// PDF to print. The file is appended if no argument has "{file}".
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// TimeoutSeconds limits each attempt, zero waits as long as it takes
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// Retries is how many more times a failed print is tried
+	Retries int `json:"retries"`
+}
+
+type BackupSettings struct {
+	// Dir defaults to the backups folder in the data directory
+	Dir         string `json:"dir"`
+	Generations int    `json:"generations"`
+	// Schedule is "write" to back up before every change or "daily"
+	Schedule string `json:"schedule"`
+}
+
+// Settings are read from the settings file. Relative paths are relative to
+// the directory of that file. Paths left out default to the directory of the
+// executable.
+type Settings struct {
+	DataDir string `json:"data_dir"`
+	// AssetsDir overrides the embedded font and template with the files of
+	// the same name in it, empty to use only the embedded ones
+	AssetsDir       string `json:"assets_dir"`
+	Template        string `json:"template"`
+	DateFormat      string `json:"date_format"`
+	IdleLockMinutes int    `json:"idle_lock_minutes"`
+	// LogLevel is debug, info, warn or error
+	LogLevel string         `json:"log_level"`
+	Print    PrintSettings  `json:"print"`
+	Backup   BackupSettings `json:"backup"`
+	Hospital Hospital       `json:"hospital"`
+
+	// FilePath is the settings file that was loaded, empty for the defaults
+	FilePath string `json:"-"`
+}
+
+// App holds the settings in use. It starts as the defaults and is replaced
+// by Load.
+var App = DefaultSettings()
+
+func DefaultSettings() Settings {
+	baseDir := executableDir()
+
+	return Settings{
+		DataDir:         baseDir,
+		Template:        "form_template.png",
+		DateFormat:      "02/01/2006",
+		IdleLockMinutes: 5,
+		LogLevel:        "info",
+		Print: PrintSettings{
+			Command:        filepath.Join(baseDir, "PDFtoPrinter.exe"),
+			Args:           []string{"{file}"},
+			TimeoutSeconds: 60,
+			Retries:        2,
+		},
+		Backup: BackupSettings{
+			Generations: 14,
+			Schedule:    "daily",
+		},
+	}
+}
+
+// Load resolves the settings file, reads it over the defaults and makes it
+// App. flagPath is the --config flag; when empty the file is looked for next
+// to the executable and then in the user config directory. Without any file
+// the defaults are used.
+func Load(flagPath string) error {
+	path, err := resolveSettingsFile(flagPath)
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		App = DefaultSettings()
+		return os.MkdirAll(App.DataDir, 0o700)
+	}
+
+	settings, err := readSettings(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config %s: %w", path, err)
+	}
+
+	App = settings
+	return os.MkdirAll(App.DataDir, 0o700)
+}
+
+func readSettings(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	baseDir := filepath.Dir(path)
+	settings := DefaultSettings()
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return Settings{}, err
+	}
+
+	settings.DataDir = resolvePath(baseDir, settings.DataDir)
+	settings.AssetsDir = resolvePath(baseDir, settings.AssetsDir)
+	settings.Backup.Dir = resolvePath(baseDir, settings.Backup.Dir)
+	if filepath.Base(settings.Print.Command) != settings.Print.Command {
+		settings.Print.Command = resolvePath(baseDir, settings.Print.Command)
+	}
+	settings.FilePath = path
+
+	if _, err := time.Parse(settings.DateFormat, time.Now().Format(settings.DateFormat)); err != nil || settings.DateFormat == "" {
+		return Settings{}, fmt.Errorf("invalid date_format %q", settings.DateFormat)
+	}
+	if err := checkDateFits(settings.DateFormat); err != nil {
+		return Settings{}, err
+	}
+
+	if _, err := parseLogLevel(settings.LogLevel); err != nil {
+		return Settings{}, fmt.Errorf("invalid log_level %q", settings.LogLevel)
+	}
+
+	return settings, nil
+}
+
+// checkDateFits reports whether dates in format are too long for the date
+// boxes of the printed sheet. Every day of a year is tried, for the longest
+// names of months and weekdays.
+func checkDateFits(format string) error {
+	longest := 0
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for d := start; d.Year() == start.Year(); d = d.AddDate(0, 0, 1) {
+		longest = max(longest, utf8.RuneCountInString(d.Format(format)))
+	}
+
+	room := min(FieldConfig[DATE].MaxChars, FieldConfig[DATE_OF_BIRTH].MaxChars, FieldConfig[DATE_OF_ADMISSION].MaxChars)
+	if longest > room {
+		return fmt.Errorf("date_format %q prints up to %d characters, the sheet has room for %d", format, longest, room)
+	}
+
+	return nil
+}
+
+func resolveSettingsFile(flagPath string) (string, error) {
+	if flagPath != "" {
+		if _, err := os.Stat(flagPath); err != nil {
+			return "", fmt.Errorf("cannot use config: %w", err)
+		}
+		return filepath.Abs(flagPath)
+	}
+
+	for _, path := range SettingsFileCandidates() {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// SettingsFileCandidates lists where the settings file is looked for, in
+// order.
+func SettingsFileCandidates() []string {
+	candidates := []string{filepath.Join(executableDir(), SettingsFileName)}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, appDirName, SettingsFileName))
+	}
+
+	return candidates
+}
+
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+func executableDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "."
+	}
+
+	return filepath.Dir(exe)
+}
+
+func (s Settings) dataFile(name string) string {
+	return filepath.Join(s.DataDir, name)
+}
+
+func (s Settings) DataFile() string    { return s.dataFile("data.csv") }
+func (s Settings) AuditFile() string   { return s.dataFile("audit.jsonl") }
+func (s Settings) HistoryFile() string { return s.dataFile("history.jsonl") }
+func (s Settings) UsersFile() string   { return s.dataFile("users.json") }
+func (s Settings) KeyFile() string     { return s.dataFile("key.json") }
+func (s Settings) OutputFile() string  { return s.dataFile("output.pdf") }
+func (s Settings) LogFile() string     { return s.dataFile("pmjay.log") }
+func (s Settings) DraftFile() string   { return s.dataFile("draft.json") }
+func (s Settings) HBPFile() string     { return s.dataFile("hbp_packages.csv") }
+func (s Settings) PincodeFile() string { return s.dataFile("pincodes.csv") }
+
+func (s Settings) BackupDir() string {
+	if s.Backup.Dir == "" {
+		return filepath.Join(s.DataDir, "backups")
+	}
+	return s.Backup.Dir
+}
+
+func (s Settings) IdleLockTimeout() time.Duration {
+	return time.Duration(s.IdleLockMinutes) * time.Minute
+}
+
+func (s Settings) PrintTimeout() time.Duration {
+	return time.Duration(s.Print.TimeoutSeconds) * time.Second
+}
+
+// Level is LogLevel as a slog level, info if it is not valid.
+func (s Settings) Level() slog.Level {
+	level, err := parseLogLevel(s.LogLevel)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
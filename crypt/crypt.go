@@ -0,0 +1,248 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	MinPassphraseLength = 8
+
+	keyFileVersion = 1
+	kdfArgon2id    = "argon2id"
+
+	// fileMagic starts every encrypted file, linePrefix every encrypted line
+	// of the JSON Lines logs
+	fileMagic  = "PMJAYENC1\n"
+	linePrefix = "enc:"
+
+	checkPlaintext = "pmjay"
+)
+
+var (
+	ErrLocked           = errors.New("data is encrypted, unlock it with the passphrase first")
+	ErrWrongPassphrase  = errors.New("wrong passphrase")
+	ErrKeyFileExists    = errors.New("encryption is already set up")
+	ErrCorruptedData    = errors.New("encrypted data is corrupted")
+	ErrPassphraseLength = fmt.Errorf("passphrase must have at least %d characters", MinPassphraseLength)
+)
+
+// keyFile holds what is needed to derive the key from the passphrase again,
+// and a sealed check value to tell a wrong passphrase apart from bad data.
+type keyFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	Salt    []byte `json:"salt"`
+	Check   []byte `json:"check"`
+}
+
+// Key encrypts and decrypts data with AES-256-GCM. A nil *Key passes
+// plaintext through, so callers do not need to care whether encryption is
+// set up.
+type Key struct {
+	aead cipher.AEAD
+}
+
+func KeyFileExists(fileStr string) bool {
+	_, err := os.Stat(fileStr)
+	return err == nil
+}
+
+// NewKeyFile sets up encryption with a new random salt and returns the key.
+func NewKeyFile(fileStr, passphrase string) (*Key, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, ErrPassphraseLength
+	}
+	if KeyFileExists(fileStr) {
+		return nil, ErrKeyFileExists
+	}
+
+	kf := keyFile{
+		Version: keyFileVersion,
+		KDF:     kdfArgon2id,
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+		Salt:    make([]byte, 16),
+	}
+	if _, err := rand.Read(kf.Salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(kf, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	kf.Check = key.seal([]byte(checkPlaintext))
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fileStr, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// LoadKey derives the key from passphrase and checks it against the key file.
+func LoadKey(fileStr, passphrase string) (*Key, error) {
+	data, err := os.ReadFile(fileStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid key file: %w", err)
+	}
+	if kf.Version != keyFileVersion || kf.KDF != kdfArgon2id {
+		return nil, fmt.Errorf("unsupported key file version %d (%s)", kf.Version, kf.KDF)
+	}
+
+	key, err := deriveKey(kf, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := key.open(kf.Check)
+	if err != nil || string(check) != checkPlaintext {
+		return nil, ErrWrongPassphrase
+	}
+
+	return key, nil
+}
+
+func deriveKey(kf keyFile, passphrase string) (*Key, error) {
+	raw := argon2.IDKey([]byte(passphrase), kf.Salt, kf.Time, kf.Memory, kf.Threads, 32)
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{aead: aead}, nil
+}
+
+// EncodeFile encrypts the contents of a whole file.
+func (k *Key) EncodeFile(plaintext []byte) []byte {
+	if k == nil {
+		return plaintext
+	}
+	return append([]byte(fileMagic), k.seal(plaintext)...)
+}
+
+// DecodeFile decrypts data written by EncodeFile. Plaintext data is returned
+// as is.
+func (k *Key) DecodeFile(data []byte) ([]byte, error) {
+	if !IsEncryptedFile(data) {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrLocked
+	}
+	return k.open(data[len(fileMagic):])
+}
+
+func IsEncryptedFile(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(fileMagic))
+}
+
+// EncodeLine encrypts a single line of a JSON Lines log.
+func (k *Key) EncodeLine(plaintext []byte) []byte {
+	if k == nil {
+		return plaintext
+	}
+	return []byte(linePrefix + base64.StdEncoding.EncodeToString(k.seal(plaintext)))
+}
+
+// DecodeLine decrypts a line written by EncodeLine. Plaintext lines are
+// returned as is.
+func (k *Key) DecodeLine(line []byte) ([]byte, error) {
+	if !bytes.HasPrefix(line, []byte(linePrefix)) {
+		return line, nil
+	}
+	if k == nil {
+		return nil, ErrLocked
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(line[len(linePrefix):])))
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+	return k.open(data)
+}
+
+// RewriteLines writes every line of the file at fileStr, as written by
+// EncodeLine, again with k. The file is replaced whole, so a failure leaves
+// it as it was. A missing file is not an error.
+func (k *Key) RewriteLines(fileStr string) error {
+	data, err := os.ReadFile(fileStr)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		line, err := k.DecodeLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		buf.Write(k.EncodeLine(line))
+		buf.WriteByte('\n')
+	}
+
+	tmpFileStr := fileStr + ".tmp"
+	if err := os.WriteFile(tmpFileStr, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFileStr, fileStr)
+}
+
+func (k *Key) seal(plaintext []byte) []byte {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func (k *Key) open(data []byte) ([]byte, error) {
+	if len(data) < k.aead.NonceSize() {
+		return nil, ErrCorruptedData
+	}
+
+	nonce, ciphertext := data[:k.aead.NonceSize()], data[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+	return plaintext, nil
+}
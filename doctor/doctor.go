@@ -0,0 +1,279 @@
+// Package doctor checks that everything printing and the records need is in
+// place, so problems show up at startup rather than at print time.
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bgics/pmjay-go/assets"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/icd"
+	"github.com/bgics/pmjay-go/store"
+	"github.com/phpdave11/gofpdf"
+)
+
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarn:
+		return "WARN"
+	}
+	return "FAIL"
+}
+
+// Result is the outcome of one check. Fix says what to do about a warning or
+// a failure.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Fix    string
+}
+
+const (
+	// aspectTolerance is how far the template's width to height ratio may be
+	// from A4's before the fields are noticeably misplaced
+	aspectTolerance = 0.02
+	// minTemplateDPI is the resolution below which the printed form looks
+	// blurred
+	minTemplateDPI = 100
+)
+
+// Run runs every check in the order they are shown.
+func Run() []Result {
+	return []Result{
+		checkAssets(),
+		checkFont(),
+		checkTemplate(),
+		checkPrinter(),
+		checkCatalogue(),
+		checkDataDir(),
+		checkStore(),
+	}
+}
+
+// Worst is the most severe status of results.
+func Worst(results []Result) Status {
+	worst := StatusOK
+	for _, r := range results {
+		worst = max(worst, r.Status)
+	}
+	return worst
+}
+
+func assetNames() []string {
+	return []string{config.FontConfig.FileStr, config.FontConfig.ZFileStr, config.App.Template}
+}
+
+func checkAssets() Result {
+	r := Result{Name: "Assets"}
+
+	var sources []string
+	for _, name := range assetNames() {
+		if _, err := assets.ReadFile(config.App.AssetsDir, name); err != nil {
+			r.Status = StatusFail
+			r.Detail = err.Error()
+			if config.App.AssetsDir == "" {
+				r.Fix = fmt.Sprintf("set assets_dir in %s to a folder with %s, or remove \"template\" to use the built-in form", settingsFileName(), name)
+			} else {
+				r.Fix = fmt.Sprintf("copy %s to %s", name, config.App.AssetsDir)
+			}
+			return r
+		}
+		sources = append(sources, fmt.Sprintf("%s (%s)", name, assets.Source(config.App.AssetsDir, name)))
+	}
+
+	r.Detail = strings.Join(sources, ", ")
+	return r
+}
+
+func checkCatalogue() Result {
+	r := Result{Name: "ICD-10 catalogue"}
+
+	c, err := icd.Load(config.App.AssetsDir)
+	if err != nil {
+		r.Status = StatusWarn
+		r.Detail = err.Error()
+		r.Fix = fmt.Sprintf("fix or remove %s, only the neonatal codes are suggested until then", icd.FullFileStr)
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("%d codes (%s)", c.Len(), assets.Source(config.App.AssetsDir, icd.FullFileStr))
+	return r
+}
+
+func checkFont() Result {
+	r := Result{Name: "Font"}
+
+	fontJSON, err := assets.ReadFile(config.App.AssetsDir, config.FontConfig.FileStr)
+	if err != nil {
+		return skipped(r, "assets")
+	}
+	fontZ, err := assets.ReadFile(config.App.AssetsDir, config.FontConfig.ZFileStr)
+	if err != nil {
+		return skipped(r, "assets")
+	}
+
+	pdf := gofpdf.New(gofpdf.OrientationPortrait, gofpdf.UnitMillimeter, gofpdf.PageSizeA4, "")
+	pdf.AddFontFromBytes(config.FontConfig.FamilyStr, config.FontConfig.StyleStr, fontJSON, fontZ)
+	pdf.SetFont(config.FontConfig.FamilyStr, config.FontConfig.StyleStr, config.FontConfig.Size)
+	if err := pdf.Error(); err != nil {
+		r.Status = StatusFail
+		r.Detail = err.Error()
+		r.Fix = fmt.Sprintf("regenerate %s and %s with gofpdf's makefont, or remove them from %s to use the built-in font",
+			config.FontConfig.FileStr, config.FontConfig.ZFileStr, config.App.AssetsDir)
+		return r
+	}
+	if pdf.GetStringWidth("0") == 0 {
+		r.Status = StatusFail
+		r.Detail = "font has no glyph widths"
+		r.Fix = fmt.Sprintf("regenerate %s with gofpdf's makefont", config.FontConfig.FileStr)
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("%s %gpt", config.FontConfig.FamilyStr, config.FontConfig.Size)
+	return r
+}
+
+func checkTemplate() Result {
+	r := Result{Name: "Template"}
+
+	data, err := assets.ReadFile(config.App.AssetsDir, config.App.Template)
+	if err != nil {
+		return skipped(r, "assets")
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("%s is not a PNG or JPEG image: %v", config.App.Template, err)
+		r.Fix = "export the form as a PNG scan of an A4 page"
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("%s %dx%d px", format, cfg.Width, cfg.Height)
+
+	a4Aspect := float64(config.A4WidthMM) / float64(config.A4HeightMM)
+	aspect := float64(cfg.Width) / float64(cfg.Height)
+	if math.Abs(aspect-a4Aspect)/a4Aspect > aspectTolerance {
+		r.Status = StatusWarn
+		r.Detail += fmt.Sprintf(", width/height %.3f but A4 is %.3f", aspect, a4Aspect)
+		r.Fix = "crop or pad the template to A4 proportions, otherwise it is stretched and the fields print off their boxes"
+		return r
+	}
+
+	dpi := float64(cfg.Width) / (float64(config.A4WidthMM) / 25.4)
+	if dpi < minTemplateDPI {
+		r.Status = StatusWarn
+		r.Detail += fmt.Sprintf(", about %.0f dpi", dpi)
+		r.Fix = fmt.Sprintf("scan the form at %d dpi or more so the printout is sharp", minTemplateDPI)
+	}
+
+	return r
+}
+
+func checkPrinter() Result {
+	r := Result{Name: "Printer"}
+
+	path, err := exec.LookPath(config.App.Print.Command)
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("print command %s not found", config.App.Print.Command)
+		if filepath.Base(config.App.Print.Command) == "PDFtoPrinter.exe" {
+			r.Fix = fmt.Sprintf("download PDFtoPrinter.exe next to the app, or set print.command in %s", settingsFileName())
+		} else {
+			r.Fix = fmt.Sprintf("install %s or set print.command in %s", filepath.Base(config.App.Print.Command), settingsFileName())
+		}
+		return r
+	}
+
+	r.Detail = path
+	return r
+}
+
+func checkDataDir() Result {
+	r := Result{Name: "Data folder", Detail: config.App.DataDir}
+
+	file, err := os.CreateTemp(config.App.DataDir, ".doctor-*")
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("cannot write to %s: %v", config.App.DataDir, err)
+		r.Fix = fmt.Sprintf("give this user write access to %s or set data_dir in %s", config.App.DataDir, settingsFileName())
+		return r
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return r
+}
+
+func checkStore() Result {
+	r := Result{Name: "Records"}
+
+	data, err := os.ReadFile(config.App.DataFile())
+	if errors.Is(err, os.ErrNotExist) {
+		r.Detail = "no records yet"
+		return r
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = err.Error()
+		r.Fix = fmt.Sprintf("give this user read access to %s", config.App.DataFile())
+		return r
+	}
+
+	if crypt.IsEncryptedFile(data) {
+		if !crypt.KeyFileExists(config.App.KeyFile()) {
+			r.Status = StatusFail
+			r.Detail = fmt.Sprintf("%s is encrypted but %s is missing", config.App.DataFile(), config.App.KeyFile())
+			r.Fix = fmt.Sprintf("copy key.json back into %s from where the data was encrypted", config.App.DataDir)
+			return r
+		}
+		r.Detail = "encrypted, read after unlocking"
+		return r
+	}
+
+	records, err := store.NewStore().ReadRecordsFile(config.App.DataFile())
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("%s cannot be read: %v", config.App.DataFile(), err)
+		r.Fix = "restore the latest good backup with `pmjay restore`"
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("%d records", len(records))
+	return r
+}
+
+func skipped(r Result, dependency string) Result {
+	r.Status = StatusFail
+	r.Detail = fmt.Sprintf("not checked, %s check failed", dependency)
+	return r
+}
+
+func settingsFileName() string {
+	if config.App.FilePath != "" {
+		return config.App.FilePath
+	}
+	return config.SettingsFileName
+}
@@ -0,0 +1,148 @@
+// Package draft keeps the form being edited on disk, so edits lost to an
+// accidental exit can be recovered on the next start.
+package draft
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// Draft is a form with edits that were not saved.
+type Draft struct {
+	Operator string    `json:"operator"`
+	SavedAt  time.Time `json:"saved_at"`
+	// Record is the form as it was last edited
+	Record model.FormData `json:"record"`
+	// Original is the form as it was opened, to tell what changed
+	Original model.FormData `json:"original"`
+	NumDays  int            `json:"num_days"`
+}
+
+// Store holds at most one draft in a file.
+type Store struct {
+	fileStr string
+	key     *crypt.Key
+	mu      sync.Mutex
+}
+
+func NewStore(fileStr string) *Store {
+	return &Store{fileStr: fileStr}
+}
+
+// SetKey sets the key the draft is encrypted with from now on.
+func (s *Store) SetKey(key *crypt.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = key
+}
+
+// Save replaces the draft with d.
+func (s *Store) Save(d Draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d.SavedAt.IsZero() {
+		d.SavedAt = time.Now()
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return s.write(data)
+}
+
+// Load returns the draft, ok is false if there is none.
+func (s *Store) Load() (d Draft, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if data == nil || err != nil {
+		return Draft{}, false, err
+	}
+
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Draft{}, false, err
+	}
+
+	return d, true, nil
+}
+
+// Clear removes the draft, if there is one.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.fileStr); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ClearSaved removes the draft if it is the one saved at savedAt, keeping a
+// draft saved since.
+func (s *Store) ClearSaved(savedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if data == nil || err != nil {
+		return err
+	}
+
+	var d Draft
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	if !d.SavedAt.Equal(savedAt) {
+		return nil
+	}
+
+	if err := os.Remove(s.fileStr); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Rewrite writes the draft again with the current key.
+func (s *Store) Rewrite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if data == nil || err != nil {
+		return err
+	}
+
+	return s.write(data)
+}
+
+// read returns the decoded draft file, nil if there is none.
+func (s *Store) read() ([]byte, error) {
+	data, err := os.ReadFile(s.fileStr)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.key.DecodeFile(data)
+}
+
+func (s *Store) write(data []byte) error {
+	tmpFileStr := s.fileStr + ".tmp"
+	if err := os.WriteFile(tmpFileStr, s.key.EncodeFile(data), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFileStr, s.fileStr)
+}
@@ -0,0 +1,254 @@
+// Package duplicate finds records that are likely the same patient entered
+// twice, and lists the fields that can be picked from either side when they
+// are merged.
+package duplicate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/model"
+)
+
+const (
+	// MinScore is the score from which a pair is reported as a duplicate
+	MinScore = 0.6
+
+	// minNameScore is how alike the names must be at least, so different
+	// babies born on the same day in the same village are not reported
+	minNameScore = 0.7
+
+	nameWeight    = 0.5
+	dobWeight     = 0.3
+	addressWeight = 0.2
+)
+
+// nameFillers are the words that do not tell patients apart, as babies are
+// entered as "B/O Rekha", "Baby of Rekha" and the like.
+var nameFillers = []string{"b", "o", "bo", "baby", "of", "child", "mr", "mrs", "smt", "shri"}
+
+// Pair is two records that may be the same patient. Score is from 0 to 1,
+// Reasons tell what is alike.
+type Pair struct {
+	A, B    model.FormData
+	Score   float64
+	Reasons []string
+}
+
+// Find returns the pairs of records scoring at least minScore, best first.
+// Siblings of a multiple birth are never paired.
+func Find(records []model.FormData, minScore float64) []Pair {
+	var pairs []Pair
+	for i, a := range records {
+		for _, b := range records[i+1:] {
+			if a.SiblingGroup != "" && a.SiblingGroup == b.SiblingGroup ||
+				a.ID == b.SiblingGroup || b.ID == a.SiblingGroup {
+				continue
+			}
+
+			if score, reasons := Score(a, b); score >= minScore {
+				pairs = append(pairs, Pair{A: a, B: b, Score: score, Reasons: reasons})
+			}
+		}
+	}
+
+	slices.SortStableFunc(pairs, func(a, b Pair) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	return pairs
+}
+
+// Score tells how likely a and b are the same patient, from the likeness of
+// their names, dates of birth and addresses.
+func Score(a, b model.FormData) (float64, []string) {
+	nameScore := similarity(nameKey(a.Name), nameKey(b.Name))
+	if nameScore < minNameScore {
+		return 0, nil
+	}
+
+	var reasons []string
+	if nameScore == 1 {
+		reasons = append(reasons, "same name")
+	} else {
+		reasons = append(reasons, fmt.Sprintf("names %.0f%% alike", nameScore*100))
+	}
+
+	var dobScore float64
+	switch days := daysApart(a.DateOfBirth, b.DateOfBirth); {
+	case days == 0:
+		dobScore = 1
+		reasons = append(reasons, "same DOB")
+	case days == 1:
+		dobScore = 0.5
+		reasons = append(reasons, "DOB a day apart")
+	}
+
+	addressScore := overlap(words(a.Address), words(b.Address))
+	if addressScore >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("addresses %.0f%% alike", addressScore*100))
+	}
+
+	return nameWeight*nameScore + dobWeight*dobScore + addressWeight*addressScore, reasons
+}
+
+// nameKey is name without fillers and punctuation, its words sorted so the
+// order they were typed in does not matter.
+func nameKey(name string) string {
+	var kept []string
+	for _, word := range words(name) {
+		if !slices.Contains(nameFillers, word) {
+			kept = append(kept, word)
+		}
+	}
+	slices.Sort(kept)
+	return strings.Join(kept, " ")
+}
+
+func words(str string) []string {
+	return strings.FieldsFunc(strings.ToLower(str), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// similarity is 1 less the edit distance of a and b over the length of the
+// longer one.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range a {
+		curr[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			curr[j+1] = min(prev[j+1]+1, curr[j]+1, prev[j]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// overlap is the share of the words of a and b that both have.
+func overlap(a, b []string) float64 {
+	all := make(map[string]bool)
+	for _, word := range append(slices.Clone(a), b...) {
+		all[word] = true
+	}
+	if len(all) == 0 {
+		return 0
+	}
+
+	common := 0
+	for word := range all {
+		if slices.Contains(a, word) && slices.Contains(b, word) {
+			common++
+		}
+	}
+	return float64(common) / float64(len(all))
+}
+
+func daysApart(a, b time.Time) int {
+	if a.IsZero() || b.IsZero() {
+		return -1
+	}
+
+	days := int(a.Sub(b).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
+
+// Field is a field of the form that is picked from one of the records when
+// they are merged.
+type Field struct {
+	Name string
+	// Value is the field of fd as shown for picking
+	Value func(fd model.FormData) string
+	// Take sets the field of dst to that of src, along with the fields that
+	// go with it
+	Take func(dst *model.FormData, src model.FormData)
+}
+
+// Fields are the fields picked when merging, in the order of the form.
+var Fields = []Field{
+	{
+		Name:  "Name",
+		Value: func(fd model.FormData) string { return fd.Name },
+		Take:  func(dst *model.FormData, src model.FormData) { dst.Name = src.Name },
+	},
+	{
+		Name:  "Address",
+		Value: func(fd model.FormData) string { return fd.Address },
+		Take: func(dst *model.FormData, src model.FormData) {
+			dst.Address = src.Address
+			dst.AddressParts = src.AddressParts
+		},
+	},
+	{
+		Name:  "Diagnosis",
+		Value: func(fd model.FormData) string { return fd.Diagnosis },
+		Take: func(dst *model.FormData, src model.FormData) {
+			dst.Diagnosis = src.Diagnosis
+			dst.DiagnosisCode = src.DiagnosisCode
+		},
+	},
+	{
+		Name: "Packages",
+		Value: func(fd model.FormData) string {
+			codes := make([]string, len(fd.Packages))
+			for i, p := range fd.Packages {
+				codes[i] = p.Code
+			}
+			return strings.Join(codes, ", ")
+		},
+		Take: func(dst *model.FormData, src model.FormData) { dst.Packages = slices.Clone(src.Packages) },
+	},
+	{
+		Name:  "Gender",
+		Value: func(fd model.FormData) string { return fd.Gender.Label() },
+		Take:  func(dst *model.FormData, src model.FormData) { dst.Gender = src.Gender },
+	},
+	{
+		Name:  "Date",
+		Value: func(fd model.FormData) string { return fd.Date.Format(config.App.DateFormat) },
+		Take:  func(dst *model.FormData, src model.FormData) { dst.Date = src.Date },
+	},
+	{
+		Name:  "Date of Admission",
+		Value: func(fd model.FormData) string { return fd.DateOfAdmission.Format(config.App.DateFormat) },
+		Take:  func(dst *model.FormData, src model.FormData) { dst.DateOfAdmission = src.DateOfAdmission },
+	},
+	{
+		Name:  "Date of Birth",
+		Value: func(fd model.FormData) string { return fd.DateOfBirth.Format(config.App.DateFormat) },
+		Take:  func(dst *model.FormData, src model.FormData) { dst.DateOfBirth = src.DateOfBirth },
+	},
+}
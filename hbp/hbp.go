@@ -0,0 +1,228 @@
+// Package hbp keeps the master of PMJAY Health Benefit Packages that claims
+// are filed against. The master is imported from a CSV file, as the packages
+// and their rates differ between states and change with each revision.
+package hbp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var CSVHeader = []string{"Code", "Name", "Specialty", "Rate", "Pre-auth"}
+
+// Package is one entry of the master.
+type Package struct {
+	Code      string
+	Name      string
+	Specialty string
+	// Rate is the package amount in rupees
+	Rate int
+	// PreAuth is set for packages that need pre-authorisation before
+	// treatment
+	PreAuth bool
+}
+
+// Master is the package master in a file. It is read on first use.
+type Master struct {
+	fileStr  string
+	packages []Package
+	loaded   bool
+	mu       sync.Mutex
+}
+
+func NewMaster(fileStr string) *Master {
+	return &Master{fileStr: fileStr}
+}
+
+// Parse reads a package master in CSV, with a header row naming the columns
+// of CSVHeader in any order. Pre-auth is yes/no, true/false or y/n.
+func Parse(r io.Reader) ([]Package, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	cols := make([]int, len(CSVHeader))
+	for i, name := range CSVHeader {
+		cols[i] = slices.IndexFunc(rows[0], func(col string) bool {
+			return strings.EqualFold(strings.TrimSpace(col), name)
+		})
+		if cols[i] == -1 {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var packages []Package
+	seen := make(map[string]bool)
+	for i, row := range rows[1:] {
+		line := i + 2
+		field := func(index int) string {
+			if cols[index] >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[cols[index]])
+		}
+
+		p := Package{
+			Code:      strings.ToUpper(field(0)),
+			Name:      field(1),
+			Specialty: field(2),
+		}
+		if p.Code == "" || p.Name == "" {
+			return nil, fmt.Errorf("line %d: code and name are required", line)
+		}
+		if seen[p.Code] {
+			return nil, fmt.Errorf("line %d: package %s is listed twice", line, p.Code)
+		}
+		seen[p.Code] = true
+
+		rate := strings.ReplaceAll(field(3), ",", "")
+		if p.Rate, err = strconv.Atoi(rate); err != nil || p.Rate < 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, field(3))
+		}
+
+		switch strings.ToLower(field(4)) {
+		case "yes", "y", "true", "1":
+			p.PreAuth = true
+		case "no", "n", "false", "0", "":
+		default:
+			return nil, fmt.Errorf("line %d: invalid pre-auth %q, expected yes or no", line, field(4))
+		}
+
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+// Import replaces the master with the packages read from r, and returns how
+// many there are. The master is left as it was if r cannot be parsed.
+func (m *Master) Import(r io.Reader) (int, error) {
+	packages, err := Parse(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(CSVHeader)
+	for _, p := range packages {
+		preAuth := "no"
+		if p.PreAuth {
+			preAuth = "yes"
+		}
+		w.Write([]string{p.Code, p.Name, p.Specialty, strconv.Itoa(p.Rate), preAuth})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tmpFileStr := m.fileStr + ".tmp"
+	if err := os.WriteFile(tmpFileStr, buf.Bytes(), 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpFileStr, m.fileStr); err != nil {
+		return 0, err
+	}
+
+	m.packages = packages
+	m.loaded = true
+
+	return len(packages), nil
+}
+
+// Packages returns every package in the master, none if it was not imported
+// yet.
+func (m *Master) Packages() ([]Package, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m.packages, nil
+}
+
+func (m *Master) Lookup(code string) (Package, bool, error) {
+	packages, err := m.Packages()
+	if err != nil {
+		return Package{}, false, err
+	}
+
+	for _, p := range packages {
+		if strings.EqualFold(p.Code, code) {
+			return p, true, nil
+		}
+	}
+	return Package{}, false, nil
+}
+
+// Search returns at most limit packages whose code starts with query or
+// whose name or specialty has every word of it, codes first.
+func (m *Master) Search(query string, limit int) ([]Package, error) {
+	packages, err := m.Packages()
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	var byCode, byName []Package
+	for _, p := range packages {
+		if len(words) == 1 && strings.HasPrefix(strings.ToLower(p.Code), words[0]) {
+			byCode = append(byCode, p)
+			continue
+		}
+
+		text := strings.ToLower(p.Name + " " + p.Specialty)
+		if !slices.ContainsFunc(words, func(word string) bool { return !strings.Contains(text, word) }) {
+			byName = append(byName, p)
+		}
+	}
+
+	matches := append(byCode, byName...)
+	return matches[:min(len(matches), limit)], nil
+}
+
+func (m *Master) load() error {
+	if m.loaded {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.fileStr)
+	if errors.Is(err, fs.ErrNotExist) {
+		m.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	packages, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.fileStr, err)
+	}
+
+	m.packages = packages
+	m.loaded = true
+	return nil
+}
@@ -0,0 +1,226 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/model"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionRevert  = "revert"
+	ActionMerge   = "merge"
+	ActionPrint   = "print"
+)
+
+// Change is the old and new value of a single field.
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Revision is one change to a record. Record holds the whole record as it
+// was after the change, so any revision can be reverted to.
+type Revision struct {
+	RecordID string         `json:"record_id"`
+	Number   int            `json:"number"`
+	Time     time.Time      `json:"time"`
+	Operator string         `json:"operator"`
+	Action   string         `json:"action"`
+	Changes  []Change       `json:"changes,omitempty"`
+	Record   model.FormData `json:"record"`
+	Note     string         `json:"note,omitempty"`
+}
+
+// Log is an append-only JSON Lines log of revisions for all records.
+type Log struct {
+	fileStr string
+	key     *crypt.Key
+	mu      sync.Mutex
+}
+
+func NewLog(fileStr string) *Log {
+	return &Log{fileStr: fileStr}
+}
+
+// SetKey sets the key lines are encrypted with from now on.
+func (l *Log) SetKey(key *crypt.Key) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.key = key
+}
+
+// Rewrite writes every line of the log again with the current key.
+func (l *Log) Rewrite() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.key.RewriteLines(l.fileStr)
+}
+
+// Append numbers r after the last revision of its record and writes it.
+func (l *Log) Append(r Revision) (Revision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	revisions, err := l.readRevisions(r.RecordID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	r.Number = len(revisions) + 1
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	file, err := os.OpenFile(l.fileStr, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return Revision{}, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	if _, err := file.Write(append(l.key.EncodeLine(line), '\n')); err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
+
+// Revisions returns the revisions of a record, oldest first.
+func (l *Log) Revisions(recordID string) ([]Revision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.readRevisions(recordID)
+}
+
+func (l *Log) readRevisions(recordID string) ([]Revision, error) {
+	file, err := os.Open(l.fileStr)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file: %v", err)
+		}
+	}()
+
+	var output []Revision
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var r Revision
+		line, err := l.key.DecodeLine(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if r.RecordID == recordID {
+			output = append(output, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// Diff lists the fields that differ between old and new, as they are shown
+// on the form.
+func Diff(old, new model.FormData, dateFormat string) []Change {
+	oldFields := formFields(old, dateFormat)
+	newFields := formFields(new, dateFormat)
+
+	var output []Change
+	for i, field := range oldFields {
+		if field.value != newFields[i].value {
+			output = append(output, Change{
+				Field: field.name,
+				Old:   field.value,
+				New:   newFields[i].value,
+			})
+		}
+	}
+
+	return output
+}
+
+type formField struct {
+	name  string
+	value string
+}
+
+func formFields(fd model.FormData, dateFormat string) []formField {
+	return []formField{
+		{"Name", fd.Name},
+		{"Address", fd.Address},
+		{"Diagnosis", fd.Diagnosis},
+		{"Diagnosis Code", fd.DiagnosisCode},
+		{"Packages", formatPackages(fd.Packages)},
+		{"Gender", fd.Gender.Label()},
+		{"Date", formatDate(fd.Date, dateFormat)},
+		{"Date of Admission", formatDate(fd.DateOfAdmission, dateFormat)},
+		{"Date of Birth", formatDate(fd.DateOfBirth, dateFormat)},
+		{"Birth Order", formatBirthOrder(fd.BirthOrder)},
+	}
+}
+
+func formatBirthOrder(order int) string {
+	if order == 0 {
+		return ""
+	}
+	return strconv.Itoa(order)
+}
+
+func formatPackages(packages []model.Package) string {
+	var parts []string
+	for _, p := range packages {
+		parts = append(parts, fmt.Sprintf("%s (Rs %d)", p.Code, p.Rate))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatDate(t time.Time, dateFormat string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateFormat)
+}
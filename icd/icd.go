@@ -0,0 +1,267 @@
+// Package icd searches the ICD-10 catalogue for diagnoses, along with the
+// diagnoses already used in the records, so the same condition is written
+// the same way every time.
+package icd
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bgics/pmjay-go/model"
+)
+
+// FullFileStr is the full ICD-10 catalogue. A file of this name in the
+// assets directory is read instead of the bundled one. It has the same format
+// as the bundled neonatal subset.
+const FullFileStr = "icd10.tsv"
+
+//go:embed neonatal.tsv
+var neonatal string
+
+//go:embed icd10.tsv
+var full string
+
+// Code is one entry of the catalogue.
+type Code struct {
+	Code        string
+	Description string
+	// Synonyms are abbreviations and other names of the condition, which
+	// match a search like the description does
+	Synonyms []string
+}
+
+type Catalogue struct {
+	codes []Code
+}
+
+// Load returns the bundled neonatal codes, followed by the full catalogue
+// from assetsDir, or the bundled one if assetsDir has none. If the full
+// catalogue cannot be read the neonatal codes are returned along with the
+// error. The catalogue is never nil.
+func Load(assetsDir string) (*Catalogue, error) {
+	codes, err := parse(neonatal)
+	if err != nil {
+		return &Catalogue{}, fmt.Errorf("bundled catalogue: %w", err)
+	}
+	c := &Catalogue{codes: codes}
+
+	data, fileStr := full, "bundled "+FullFileStr
+	if assetsDir != "" {
+		path := filepath.Join(assetsDir, FullFileStr)
+		override, err := os.ReadFile(path)
+		if err == nil {
+			data, fileStr = string(override), path
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return c, err
+		}
+	}
+
+	fullCodes, err := parse(data)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", fileStr, err)
+	}
+
+	seen := make(map[string]struct{}, len(c.codes)+len(fullCodes))
+	for _, code := range c.codes {
+		seen[code.Code] = struct{}{}
+	}
+	for _, code := range fullCodes {
+		if _, found := seen[code.Code]; !found {
+			seen[code.Code] = struct{}{}
+			c.codes = append(c.codes, code)
+		}
+	}
+
+	return c, nil
+}
+
+// parse reads a catalogue of tab separated code, description and synonyms
+// lines. Blank lines and lines starting with # are skipped.
+func parse(data string) ([]Code, error) {
+	var codes []Code
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("line %d: expected code and description", i+1)
+		}
+
+		code := Code{Code: strings.ToUpper(fields[0]), Description: fields[1]}
+		if len(fields) > 2 && fields[2] != "" {
+			code.Synonyms = strings.Split(fields[2], ";")
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// Len is the number of codes in the catalogue.
+func (c *Catalogue) Len() int {
+	return len(c.codes)
+}
+
+func (c *Catalogue) Lookup(code string) (Code, bool) {
+	for _, entry := range c.codes {
+		if strings.EqualFold(entry.Code, code) {
+			return entry, true
+		}
+	}
+	return Code{}, false
+}
+
+// Use is a diagnosis as written in the records, with how many records have
+// it.
+type Use struct {
+	Text  string
+	Code  string
+	Count int
+}
+
+// Uses counts the diagnoses of records, most used first. Diagnoses that
+// differ only in case or surrounding spaces count as one, written as in the
+// first record that has it.
+func Uses(records []model.FormData) []Use {
+	var uses []Use
+	index := make(map[string]int)
+
+	for _, record := range records {
+		key := normalize(record.Diagnosis)
+		if key == "" {
+			continue
+		}
+
+		i, found := index[key]
+		if !found {
+			i = len(uses)
+			index[key] = i
+			uses = append(uses, Use{Text: strings.TrimSpace(record.Diagnosis)})
+		}
+
+		uses[i].Count++
+		if uses[i].Code == "" {
+			uses[i].Code = record.DiagnosisCode
+		}
+	}
+
+	slices.SortStableFunc(uses, func(a, b Use) int {
+		return b.Count - a.Count
+	})
+
+	return uses
+}
+
+// Suggestion is a diagnosis to offer for what was typed. Count is the number
+// of records that already use it, 0 for one only from the catalogue.
+type Suggestion struct {
+	Text  string
+	Code  string
+	Count int
+}
+
+// maxPastSuggestions is how many of the suggestions at most come from the
+// records, so the catalogue always gets a say
+const maxPastSuggestions = 3
+
+// Suggest returns at most limit diagnoses matching query: the most used
+// matching ones from past, then the best matches from the catalogue.
+func (c *Catalogue) Suggest(query string, past []Use, limit int) []Suggestion {
+	words := strings.Fields(normalize(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	add := func(s Suggestion) {
+		if key := normalize(s.Text); !seen[key] && len(suggestions) < limit {
+			seen[key] = true
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	for _, use := range past {
+		if len(suggestions) == maxPastSuggestions {
+			break
+		}
+		if matchWords(words, use.Text) || (use.Code != "" && matchCode(words, use.Code)) {
+			add(Suggestion(use))
+		}
+	}
+
+	type match struct {
+		code  Code
+		score int
+	}
+	var matches []match
+	for _, code := range c.codes {
+		if score, ok := scoreCode(words, code); ok {
+			matches = append(matches, match{code, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return a.score - b.score
+	})
+
+	for _, m := range matches {
+		add(Suggestion{Text: m.code.Description, Code: m.code.Code})
+	}
+
+	return suggestions
+}
+
+// scoreCode tells whether code matches the words of a query and how well,
+// lower is better: the code itself, then a synonym or description starting
+// with the query, then one with every word somewhere in it.
+func scoreCode(words []string, code Code) (int, bool) {
+	if matchCode(words, code.Code) {
+		return 0, true
+	}
+
+	query := strings.Join(words, " ")
+	texts := append([]string{code.Description}, code.Synonyms...)
+	for _, text := range texts {
+		if strings.HasPrefix(normalize(text), query) {
+			return 1, true
+		}
+	}
+	for _, text := range texts {
+		if matchWords(words, text) {
+			return 2, true
+		}
+	}
+
+	return 0, false
+}
+
+func matchCode(words []string, code string) bool {
+	return len(words) == 1 && strings.HasPrefix(strings.ToLower(code), words[0])
+}
+
+// matchWords reports whether every word is the start of a word of text.
+func matchWords(words []string, text string) bool {
+	textWords := strings.FieldsFunc(normalize(text), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '-' || r == '(' || r == ')'
+	})
+
+	for _, word := range words {
+		if !slices.ContainsFunc(textWords, func(w string) bool { return strings.HasPrefix(w, word) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalize(str string) string {
+	return strings.Join(strings.Fields(strings.ToLower(str)), " ")
+}
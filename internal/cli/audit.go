@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/config"
+)
+
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	patient := fs.String("patient", "", "patient ID or part of the patient name")
+	from := fs.String("from", "", "first day to include ("+config.App.DateFormat+")")
+	to := fs.String("to", "", "last day to include ("+config.App.DateFormat+")")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := audit.Filter{Patient: *patient}
+
+	var err error
+	if filter.From, err = parseFlagDate(*from); err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	if filter.To, err = parseFlagDate(*to); err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+
+	entries, err := svc.QueryAudit(user, filter)
+	if err != nil {
+		return fmt.Errorf("cannot read audit log: %w", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tOPERATOR\tACTION\tPATIENT ID\tPATIENT\tTEMPLATE\tDATES\tPRINTER\tOUTCOME")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format(config.App.DateFormat+" 15:04:05"),
+			e.Operator,
+			e.Action,
+			e.PatientID,
+			e.PatientName,
+			e.Template,
+			strings.Join(e.Dates, ","),
+			e.Printer,
+			e.Outcome,
+		)
+	}
+
+	return w.Flush()
+}
+
+func parseFlagDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(config.App.DateFormat, value)
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgics/pmjay-go/backup"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/service"
+)
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	list := fs.Bool("list", false, "list the backups and check their checksums")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		backups, err := svc.ListBackups(user)
+		if err != nil {
+			return err
+		}
+		return printBackups(backups)
+	}
+
+	b, err := svc.CreateBackup(user)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("backed up to %s\n", b.Path)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	diffOnly := fs.Bool("diff", false, "only show what restoring would change")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: pmjay restore [-diff] BACKUP, see pmjay backup -list for names")
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+
+	backups, err := svc.ListBackups(user)
+	if err != nil {
+		return err
+	}
+
+	var b backup.Backup
+	for _, candidate := range backups {
+		if candidate.Name == fs.Arg(0) {
+			b = candidate
+		}
+	}
+	if b.Name == "" {
+		return fmt.Errorf("backup %q not found in %s", fs.Arg(0), config.App.BackupDir())
+	}
+
+	diff, err := svc.DiffBackup(user, b)
+	if err != nil {
+		return err
+	}
+	printBackupDiff(diff)
+
+	if *diffOnly {
+		return nil
+	}
+
+	if err := svc.RestoreBackup(user, b); err != nil {
+		return err
+	}
+
+	fmt.Printf("restored %s, the previous data was backed up first\n", b.Name)
+	return nil
+}
+
+func printBackups(backups []backup.Backup) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTIME\tSIZE\tCHECKSUM")
+	for _, b := range backups {
+		status := "ok"
+		if err := backup.Verify(b); err != nil {
+			status = err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", b.Name, b.Time.Format(config.App.DateFormat+" 15:04:05"), b.Size, status)
+	}
+
+	return w.Flush()
+}
+
+func printBackupDiff(diff service.BackupDiff) {
+	if diff.IsEmpty() {
+		fmt.Println("the backup matches the current data")
+		return
+	}
+
+	for _, record := range diff.Added {
+		fmt.Printf("+ %s\n", record.Name)
+	}
+	for _, record := range diff.Removed {
+		fmt.Printf("- %s\n", record.Name)
+	}
+	for _, rc := range diff.Changed {
+		fmt.Printf("~ %s\n", rc.Record.Name)
+		for _, change := range rc.Changes {
+			fmt.Printf("    %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+
+	fmt.Println(strings.Repeat("-", 40))
+}
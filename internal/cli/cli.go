@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"audit":    {"query the print and save audit log", runAudit},
+	"backup":   {"back up the data file now or list the backups", runBackup},
+	"config":   {"show the settings in use or write them to a file", runConfig},
+	"doctor":   {"check assets, printer and data file and suggest fixes", runDoctor},
+	"encrypt":  {"encrypt the plaintext data file and logs with a passphrase", runEncrypt},
+	"export":   {"export the records as plaintext CSV (admin only)", runExport},
+	"packages": {"import or search the HBP package master, or total the claims", runPackages},
+	"pincodes": {"import or search the pincode directory addresses are filled in from", runPincodes},
+	"restore":  {"compare a backup with the current data and restore it", runRestore},
+	"users":    {"list, add and remove operators or change their PIN", runUsers},
+}
+
+// Run runs the command named by args[0] with the remaining arguments.
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage(os.Stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return cmd.run(args[1:])
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("usage: pmjay [--config FILE] [command] [flags]\n\n")
+	b.WriteString("Without a command the form app is started.\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-10s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprint(w, b.String())
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bgics/pmjay-go/config"
+)
+
+func runConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	write := fs.String("write", "", "write the settings in use to this file, e.g. to start a new settings file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(config.App, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if *write != "" {
+		if err := os.MkdirAll(filepath.Dir(*write), 0o700); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(*write, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(data, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	if config.App.FilePath == "" {
+		fmt.Println("# no settings file, using defaults. Looked for:")
+		for _, path := range config.SettingsFileCandidates() {
+			fmt.Printf("#   %s\n", path)
+		}
+	} else {
+		fmt.Printf("# %s\n", config.App.FilePath)
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
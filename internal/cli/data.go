@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/service"
+)
+
+func runEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if crypt.KeyFileExists(config.App.KeyFile()) {
+		return crypt.ErrKeyFileExists
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readSecret("New passphrase: ")
+	if err != nil {
+		return err
+	}
+	if len(passphrase) < crypt.MinPassphraseLength {
+		return crypt.ErrPassphraseLength
+	}
+	confirm, err := readSecret("Confirm passphrase: ")
+	if err != nil {
+		return err
+	}
+	if passphrase != confirm {
+		return fmt.Errorf("passphrases do not match")
+	}
+
+	if err := service.NewDefaultService().EncryptData(user, config.App.KeyFile(), passphrase); err != nil {
+		return err
+	}
+
+	fmt.Println("data encrypted, the passphrase is needed to start the app from now on")
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the plaintext CSV to")
+	includeDeleted := fs.Bool("deleted", false, "include records in the trash")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(*out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = svc.ExportRecords(user, file, *includeDeleted)
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a denied or broken export leaves no file behind to block the next
+		if removeErr := os.Remove(*out); removeErr != nil {
+			log.Printf("error removing file: %v", removeErr)
+		}
+		return err
+	}
+
+	fmt.Printf("records exported to %s, this file is not encrypted\n", *out)
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bgics/pmjay-go/doctor"
+)
+
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	results := doctor.Run()
+	failed := 0
+	for _, r := range results {
+		fmt.Printf("%-4s  %-12s %s\n", r.Status, r.Name, r.Detail)
+		if r.Fix != "" {
+			fmt.Printf("      %-12s fix: %s\n", "", r.Fix)
+		}
+		if r.Status == doctor.StatusFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgics/pmjay-go/config"
+)
+
+const packagesUsage = "usage: pmjay packages import FILE | search QUERY | report [-from DATE] [-to DATE]"
+
+func runPackages(args []string) error {
+	if len(args) == 0 {
+		return errors.New(packagesUsage)
+	}
+
+	fs := flag.NewFlagSet("packages "+args[0], flag.ContinueOnError)
+	from := fs.String("from", "", "first admission day to include ("+config.App.DateFormat+")")
+	to := fs.String("to", "", "last admission day to include ("+config.App.DateFormat+")")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "import":
+		if fs.NArg() != 1 {
+			return errors.New(packagesUsage)
+		}
+
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		user, err := login()
+		if err != nil {
+			return err
+		}
+
+		n, err := svc.ImportPackages(user, f)
+		if err != nil {
+			return fmt.Errorf("cannot import %s: %w", fs.Arg(0), err)
+		}
+
+		fmt.Printf("imported %d packages\n", n)
+		return nil
+	case "search":
+		packages, err := svc.SearchPackages(strings.Join(fs.Args(), " "), 50)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tNAME\tSPECIALTY\tRATE\tPRE-AUTH")
+		for _, p := range packages {
+			preAuth := ""
+			if p.PreAuth {
+				preAuth = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", p.Code, p.Name, p.Specialty, p.Rate, preAuth)
+		}
+		return w.Flush()
+	case "report":
+		fromDate, err := parseFlagDate(*from)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		toDate, err := parseFlagDate(*to)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+
+		user, err := login()
+		if err != nil {
+			return err
+		}
+
+		claims, err := svc.PackageClaims(user, fromDate, toDate)
+		if err != nil {
+			return err
+		}
+
+		total := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOA\tPATIENT ID\tPATIENT\tPACKAGES\tAMOUNT")
+		for _, record := range claims {
+			codes := make([]string, len(record.Packages))
+			for i, p := range record.Packages {
+				codes[i] = p.Code
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+				record.DateOfAdmission.Format(config.App.DateFormat),
+				record.ID,
+				record.Name,
+				strings.Join(codes, ","),
+				record.PackageTotal(),
+			)
+			total += record.PackageTotal()
+		}
+		fmt.Fprintf(w, "TOTAL\t\t\t%d admissions\t%d\n", len(claims), total)
+		return w.Flush()
+	}
+
+	return errors.New(packagesUsage)
+}
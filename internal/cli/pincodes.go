@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgics/pmjay-go/pincode"
+)
+
+const pincodesUsage = "usage: pmjay pincodes import FILE | search PLACE-OR-PIN"
+
+func runPincodes(args []string) error {
+	if len(args) == 0 {
+		return errors.New(pincodesUsage)
+	}
+
+	svc, err := openService()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) != 2 {
+			return errors.New(pincodesUsage)
+		}
+
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		user, err := login()
+		if err != nil {
+			return err
+		}
+
+		n, err := svc.ImportPincodes(user, f)
+		if err != nil {
+			return fmt.Errorf("cannot import %s: %w", args[1], err)
+		}
+
+		fmt.Printf("imported %d post offices\n", n)
+		return nil
+	case "search":
+		query := strings.Join(args[1:], " ")
+		field := pincode.Office
+		if strings.Trim(query, "0123456789") == "" {
+			field = pincode.PIN
+		}
+
+		entries, err := svc.SearchAddresses(field, query, pincode.Entry{}, 50)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "OFFICE\tBLOCK\tDISTRICT\tSTATE\tPIN")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Office, e.Block, e.District, e.State, e.PIN)
+		}
+		return w.Flush()
+	}
+
+	return errors.New(pincodesUsage)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/service"
+)
+
+// openService returns the service, asking for the passphrase if the data is
+// encrypted.
+func openService() (*service.Service, error) {
+	svc := service.NewDefaultService()
+
+	if crypt.KeyFileExists(config.App.KeyFile()) {
+		passphrase, err := readSecret("Passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := crypt.LoadKey(config.App.KeyFile(), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		svc.SetKey(key)
+	}
+
+	return svc, nil
+}
+
+// login asks for the name and PIN of an operator.
+func login() (auth.User, error) {
+	fmt.Print("Name: ")
+	name, err := stdinReader.ReadString('\n')
+	if err != nil && name == "" {
+		return auth.User{}, err
+	}
+
+	pin, err := readSecret("PIN: ")
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	return auth.NewUsers(config.App.UsersFile()).Authenticate(strings.TrimSpace(name), pin)
+}
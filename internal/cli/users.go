@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/charmbracelet/x/term"
+)
+
+const usersUsage = "usage: pmjay users list | add -name NAME [-role ROLE] | pin -name NAME | role -name NAME -role ROLE | remove -name NAME"
+
+func runUsers(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usersUsage)
+	}
+
+	users := auth.NewUsers(config.App.UsersFile())
+
+	fs := flag.NewFlagSet("users "+args[0], flag.ContinueOnError)
+	name := fs.String("name", "", "name of the user")
+	roleStr := fs.String("role", string(auth.RoleNurse), fmt.Sprintf("role of the user, one of %v", auth.Roles))
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if args[0] != "list" && strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	role, err := auth.ParseRole(*roleStr)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+	case "pin":
+		if err := requireSelfOrAdmin(*name); err != nil {
+			return err
+		}
+	default:
+		if err := requireAdmin(users); err != nil {
+			return err
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		list, err := users.List()
+		if err != nil {
+			return err
+		}
+		for _, u := range list {
+			fmt.Printf("%s\t%s\n", u.Name, u.Role)
+		}
+		return nil
+	case "add":
+		pin, err := readNewPIN()
+		if err != nil {
+			return err
+		}
+		return users.Add(*name, pin, role)
+	case "pin":
+		pin, err := readNewPIN()
+		if err != nil {
+			return err
+		}
+		return users.SetPIN(*name, pin)
+	case "role":
+		roleSet := false
+		fs.Visit(func(f *flag.Flag) { roleSet = roleSet || f.Name == "role" })
+		if !roleSet {
+			return fmt.Errorf("-role is required")
+		}
+		return users.SetRole(*name, role)
+	case "remove":
+		return users.Remove(*name)
+	}
+
+	return errors.New(usersUsage)
+}
+
+// requireAdmin asks an admin to log in before the users are changed. While
+// there are no users yet the first one is added without a login.
+func requireAdmin(users *auth.Users) error {
+	list, err := users.List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+
+	user, err := login()
+	if err != nil {
+		return err
+	}
+	return user.Require(auth.PermManageUsers)
+}
+
+// requireSelfOrAdmin asks for a login before the PIN of name is changed,
+// either by that user with their current PIN or by an admin.
+func requireSelfOrAdmin(name string) error {
+	user, err := login()
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Name, strings.TrimSpace(name)) {
+		return nil
+	}
+	return user.Require(auth.PermManageUsers)
+}
+
+func readNewPIN() (string, error) {
+	pin, err := readSecret("PIN: ")
+	if err != nil {
+		return "", err
+	}
+	if err := auth.ValidatePIN(pin); err != nil {
+		return "", err
+	}
+
+	confirm, err := readSecret("Confirm PIN: ")
+	if err != nil {
+		return "", err
+	}
+	if pin != confirm {
+		return "", fmt.Errorf("PINs do not match")
+	}
+
+	return pin, nil
+}
+
+// readSecret reads a line from stdin without echoing it when stdin is a
+// terminal.
+func readSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		secret, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		return strings.TrimSpace(string(secret)), err
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
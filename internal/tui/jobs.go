@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Job is a print or save running in the background.
+type Job struct {
+	ID int
+	// Action is what is being done, e.g. "Printing"
+	Action string
+	// Done describes the job once it succeeded, e.g. "Printed 2 pages"
+	Done    string
+	Patient string
+}
+
+type JobDoneMsg struct {
+	Job Job
+	Err error
+}
+
+type NoticeExpiredMsg struct {
+	seq int
+}
+
+// Jobs tracks the jobs running in the background and shows them in a status
+// bar on every page, along with the last one that succeeded. Jobs run one at
+// a time in the order they were started, so two prints never write the same
+// PDF at once and a print runs after the save started before it.
+type Jobs struct {
+	running []Job
+	nextID  int
+	// last is closed when the job started last has finished
+	last chan struct{}
+
+	spinner spinner.Model
+
+	// notice is the last job that succeeded, until it expires
+	notice    string
+	noticeSeq int
+}
+
+func NewJobs() *Jobs {
+	return &Jobs{
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(InfoColor))),
+	}
+}
+
+// Start queues run as a job and returns the command that runs it.
+func (j *Jobs) Start(action, done, patient string, run func() error) tea.Cmd {
+	j.nextID++
+	job := Job{ID: j.nextID, Action: action, Done: done, Patient: patient}
+	j.running = append(j.running, job)
+
+	// each job waits for the one started before it
+	prev := j.last
+	finished := make(chan struct{})
+	j.last = finished
+
+	cmd := func() tea.Msg {
+		if prev != nil {
+			<-prev
+		}
+		defer close(finished)
+
+		return JobDoneMsg{Job: job, Err: run()}
+	}
+
+	if len(j.running) == 1 {
+		return tea.Batch(cmd, j.spinner.Tick)
+	}
+	return cmd
+}
+
+// Running reports whether any job has not finished yet.
+func (j *Jobs) Running() bool {
+	return len(j.running) > 0
+}
+
+// Update handles the messages of the jobs and their status bar.
+func (j *Jobs) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case JobDoneMsg:
+		for i, job := range j.running {
+			if job.ID == msg.Job.ID {
+				j.running = append(j.running[:i], j.running[i+1:]...)
+				break
+			}
+		}
+
+		if msg.Err != nil {
+			// failures go to the result area of the page, where they stay
+			// until dismissed
+			return resultCmd(ResultMsg{
+				Severity: SeverityError,
+				Text:     fmt.Sprintf("%s failed for %s: %v", msg.Job.Action, msg.Job.Patient, msg.Err),
+				Err:      msg.Err,
+			})
+		}
+
+		j.notice = fmt.Sprintf("✓ %s for %s", msg.Job.Done, msg.Job.Patient)
+		return j.expireNoticeCmd()
+	case spinner.TickMsg:
+		if !j.Running() {
+			return nil
+		}
+
+		var cmd tea.Cmd
+		j.spinner, cmd = j.spinner.Update(msg)
+		return cmd
+	case NoticeExpiredMsg:
+		if msg.seq == j.noticeSeq {
+			j.notice = ""
+		}
+	}
+
+	return nil
+}
+
+func (j *Jobs) expireNoticeCmd() tea.Cmd {
+	j.noticeSeq++
+	seq := j.noticeSeq
+
+	return tea.Tick(config.NoticeTimeout, func(time.Time) tea.Msg {
+		return NoticeExpiredMsg{seq: seq}
+	})
+}
+
+// View is the status bar, empty when nothing is running or to report.
+func (j *Jobs) View() string {
+	var lines []string
+
+	if j.Running() {
+		job := j.running[0]
+		// the spinner frames end in a space
+		text := fmt.Sprintf("%s%s for %s", j.spinner.View(), job.Action, job.Patient)
+		if queued := len(j.running) - 1; queued > 0 {
+			text += fmt.Sprintf(" (%d more queued)", queued)
+		}
+		lines = append(lines, text)
+	}
+
+	if j.notice != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(InfoColor).Render(j.notice))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return StatusBarStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -1,26 +1,59 @@
 package tui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"fmt"
+	"time"
+
+	"github.com/bgics/pmjay-go/auth"
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 const (
 	START_PAGE = iota
 	SEARCH_PAGE
 	FORM_PAGE
+	AUDIT_PAGE
+	LOGIN_PAGE
+	TRASH_PAGE
+	HISTORY_PAGE
+	BACKUP_PAGE
+	DUPLICATE_PAGE
 )
 
 type PageIndex int
 
+var pageNames = map[PageIndex]string{
+	START_PAGE:     "start",
+	SEARCH_PAGE:    "search",
+	FORM_PAGE:      "form",
+	AUDIT_PAGE:     "audit",
+	LOGIN_PAGE:     "login",
+	TRASH_PAGE:     "trash",
+	HISTORY_PAGE:   "history",
+	BACKUP_PAGE:    "backup",
+	DUPLICATE_PAGE: "duplicates",
+}
+
+func (p PageIndex) String() string {
+	if name, ok := pageNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("page %d", int(p))
+}
+
 type ChangePageMsg struct {
 	To PageIndex
 }
 
-type FatalErrorMsg struct {
-	Err error
+type LoginMsg struct {
+	User auth.User
 }
 
-type ErrorMsg struct {
-	Err error
-}
+type LockMsg struct{}
+
+type UnlockMsg struct{}
+
+type IdleCheckMsg struct{}
 
 func ChangePageCmd(to PageIndex) tea.Cmd {
 	return func() tea.Msg {
@@ -30,18 +63,22 @@ func ChangePageCmd(to PageIndex) tea.Cmd {
 	}
 }
 
-func FatalErrorCmd(err error) tea.Cmd {
+func LoginCmd(user auth.User) tea.Cmd {
 	return func() tea.Msg {
-		return FatalErrorMsg{
-			Err: err,
+		return LoginMsg{
+			User: user,
 		}
 	}
 }
 
-func ErrorCmd(err error) tea.Cmd {
+func UnlockCmd() tea.Cmd {
 	return func() tea.Msg {
-		return ErrorMsg{
-			Err: err,
-		}
+		return UnlockMsg{}
 	}
 }
+
+func IdleCheckCmd(after time.Duration) tea.Cmd {
+	return tea.Tick(after, func(time.Time) tea.Msg {
+		return IdleCheckMsg{}
+	})
+}
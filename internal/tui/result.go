@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarn
+	SeverityError
+	// SeverityFatal ends the app
+	SeverityFatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarn:
+		return "WARN"
+	case SeverityError:
+		return "ERROR"
+	}
+	return "FATAL"
+}
+
+func (s Severity) color() lipgloss.TerminalColor {
+	switch s {
+	case SeverityInfo:
+		return InfoColor
+	case SeverityWarn:
+		return WarnColor
+	}
+	return ErrorColor
+}
+
+// ResultMsg is how a command reports its outcome to the operator. Every
+// command that can fail returns one instead of dropping the error. It is
+// shown on the current page until it is dismissed or the page changes.
+type ResultMsg struct {
+	Severity Severity
+	Text     string
+	// Err is the error behind an error or fatal result
+	Err error
+}
+
+func (r ResultMsg) String() string {
+	if r.Text == "" && r.Err != nil {
+		return r.Err.Error()
+	}
+	return r.Text
+}
+
+func InfoCmd(text string) tea.Cmd {
+	return resultCmd(ResultMsg{Severity: SeverityInfo, Text: text})
+}
+
+func WarnCmd(text string) tea.Cmd {
+	return resultCmd(ResultMsg{Severity: SeverityWarn, Text: text})
+}
+
+func ErrorCmd(err error) tea.Cmd {
+	return resultCmd(ResultMsg{Severity: SeverityError, Err: err})
+}
+
+func FatalErrorCmd(err error) tea.Cmd {
+	return resultCmd(ResultMsg{Severity: SeverityFatal, Err: err})
+}
+
+func resultCmd(msg ResultMsg) tea.Cmd {
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// RenderResult renders the result area of a page, empty without a result.
+func RenderResult(r *ResultMsg) string {
+	if r == nil {
+		return ""
+	}
+
+	text := lipgloss.NewStyle().
+		Foreground(r.Severity.color()).
+		Render(fmt.Sprintf("[%s] %s", r.Severity, r))
+	hint := lipgloss.NewStyle().
+		Foreground(InactiveColor).
+		Render("  ctrl+x dismiss")
+
+	return ResultStyle.Render(text + hint)
+}
+
+// RenderError renders an error a page keeps itself, such as one from loading
+// its data, the same way as an error result.
+func RenderError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return ErrStyle.Render(fmt.Sprintf("[%s] %v", SeverityError, err))
+}
@@ -1,20 +1,34 @@
 package tui
 
 import (
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/draft"
 	"github.com/bgics/pmjay-go/model"
-	"github.com/bgics/pmjay-go/store"
+	"github.com/bgics/pmjay-go/service"
 )
 
 type SharedState struct {
 	SelectedRecord model.FormData
 	LastPageIndex  PageIndex
-	Store          *store.Store
-	Error          error
+	Service        *service.Service
+	Users          *auth.Users
+	User           auth.User
+	Jobs           *Jobs
+	// Draft is an unsaved form to recover, opened by the next form page
+	Draft *draft.Draft
+	// Result is the outcome of the last command, shown on the current page
+	Result *ResultMsg
+	// Zones are the parts of the current page that can be clicked
+	Zones *Zones
 }
 
 func NewSharedState() *SharedState {
 	s := &SharedState{}
-	s.Store = store.NewStore()
+	s.Service = service.NewDefaultService()
+	s.Users = auth.NewUsers(config.App.UsersFile())
+	s.Jobs = NewJobs()
+	s.Zones = NewZones()
 
 	return s
 }
@@ -2,44 +2,96 @@ package starter
 
 import (
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/doctor"
 	"github.com/bgics/pmjay-go/internal/tui"
 	"github.com/bgics/pmjay-go/internal/tui/view"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type Model struct {
 	ExitError    error
 	currentModel tea.Model
 	sharedState  *tui.SharedState
+
+	loggedIn     bool
+	lastActivity time.Time
+	// quitArmed is set by a ctrl+c while jobs are running, a second one quits
+	quitArmed bool
+	// draftChecked is set once the first operator to sign in has been
+	// offered the form left unsaved by the last run
+	draftChecked bool
+	// lockedModel is the page that was open when the session got locked
+	lockedModel tea.Model
 }
 
 func NewModel() *Model {
 	s := tui.NewSharedState()
+
+	var firstModel tea.Model = view.NewLoginPageModel(s, "")
+	if crypt.KeyFileExists(config.App.KeyFile()) {
+		firstModel = view.NewUnlockPageModel(s)
+	}
+
+	if results := doctor.Run(); doctor.Worst(results) != doctor.StatusOK {
+		firstModel = view.NewCheckPageModel(results, firstModel)
+	}
+
 	return &Model{
-		currentModel: view.NewStartPageModel(s),
+		currentModel: firstModel,
 		sharedState:  s,
+		lastActivity: time.Now(),
 	}
 }
 
 func (m *Model) Init() tea.Cmd {
-	return m.currentModel.Init()
+	return tea.Batch(m.currentModel.Init(), idleCheckCmd(config.App.IdleLockTimeout()))
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.lastActivity = time.Now()
+
+		if msg.String() != "ctrl+c" {
+			m.quitArmed = false
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
+			if m.sharedState.Jobs.Running() && !m.quitArmed {
+				m.quitArmed = true
+				return m, tui.WarnCmd("a job is still running, press ctrl+c again to quit anyway")
+			}
+			m.flushDraft()
 			return m, tea.Quit
+		case "ctrl+l":
+			if m.loggedIn {
+				return m, m.lock()
+			}
+		case "ctrl+x":
+			if m.sharedState.Result != nil {
+				m.sharedState.Result = nil
+				return m, nil
+			}
 		}
-	case tui.FatalErrorMsg:
-		m.ExitError = msg.Err
-		return m, tea.Quit
-	case tui.ErrorMsg:
-		m.sharedState.Error = msg.Err
-		return m, nil
+	case tea.MouseMsg:
+		m.lastActivity = time.Now()
+	case tui.JobDoneMsg, spinner.TickMsg, tui.NoticeExpiredMsg:
+		return m, m.sharedState.Jobs.Update(msg)
+	case tui.ResultMsg:
+		return m, m.showResult(msg)
 	case tui.ChangePageMsg:
+		if !m.loggedIn {
+			return m, m.changeLockedPage(msg.To)
+		}
+
 		if err := m.changePage(msg.To); err != nil {
 			return m, tui.FatalErrorCmd(err)
 		}
@@ -47,6 +99,29 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := m.currentModel.Init()
 
 		return m, cmd
+	case tui.UnlockMsg:
+		slog.Info("data unlocked")
+		m.currentModel = view.NewLoginPageModel(m.sharedState, "")
+		return m, m.currentModel.Init()
+	case tui.LoginMsg:
+		return m, m.login(msg)
+	case tui.LockMsg:
+		if m.loggedIn {
+			return m, m.lock()
+		}
+		return m, nil
+	case tui.IdleCheckMsg:
+		remaining := config.App.IdleLockTimeout() - time.Since(m.lastActivity)
+		if remaining > 0 {
+			return m, idleCheckCmd(remaining)
+		}
+
+		var cmd tea.Cmd
+		if m.loggedIn {
+			cmd = m.lock()
+		}
+
+		return m, tea.Batch(cmd, idleCheckCmd(config.App.IdleLockTimeout()))
 	}
 
 	var cmd tea.Cmd
@@ -55,12 +130,138 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// showResult logs msg and shows it on the current page, or ends the app if it
+// is fatal.
+func (m *Model) showResult(msg tui.ResultMsg) tea.Cmd {
+	attrs := []any{"severity", msg.Severity, "user", m.sharedState.User.Name}
+	if msg.Err != nil {
+		attrs = append(attrs, "err", msg.Err)
+	} else {
+		attrs = append(attrs, "text", msg.Text)
+	}
+
+	switch msg.Severity {
+	case tui.SeverityFatal:
+		slog.Error("fatal error", attrs...)
+		m.ExitError = msg.Err
+		m.flushDraft()
+		return tea.Quit
+	case tui.SeverityError:
+		slog.Error("result shown", attrs...)
+	case tui.SeverityWarn:
+		slog.Warn("result shown", attrs...)
+	default:
+		slog.Info("result shown", attrs...)
+	}
+
+	m.sharedState.Result = &msg
+	return nil
+}
+
+// idleCheckCmd schedules the next idle check, unless auto-lock is turned off
+// with a timeout of zero.
+func idleCheckCmd(after time.Duration) tea.Cmd {
+	if config.App.IdleLockTimeout() <= 0 {
+		return nil
+	}
+	return tui.IdleCheckCmd(after)
+}
+
 func (m *Model) View() string {
-	return m.currentModel.View()
+	if !m.loggedIn {
+		return m.sharedState.Zones.Scan(m.currentModel.View())
+	}
+
+	return m.sharedState.Zones.Scan(lipgloss.JoinVertical(lipgloss.Left, m.currentModel.View(), m.sharedState.Jobs.View()))
+}
+
+// lock keeps the current page aside and asks for the PIN again.
+func (m *Model) lock() tea.Cmd {
+	slog.Info("session locked", "user", m.sharedState.User.Name)
+	m.flushDraft()
+	m.loggedIn = false
+	m.lockedModel = m.currentModel
+	m.currentModel = view.NewLoginPageModel(m.sharedState, m.sharedState.User.Name)
+
+	return m.currentModel.Init()
+}
+
+// draftPage is a page that keeps its unsaved edits as a draft.
+type draftPage interface {
+	FlushDraft()
+}
+
+// flushDraft writes the draft of the current page at once, as the delayed
+// save would not run once the app quits or locks.
+func (m *Model) flushDraft() {
+	if page, ok := m.currentModel.(draftPage); ok {
+		page.FlushDraft()
+	}
+}
+
+// login resumes the locked page if the same operator signs back in, otherwise
+// it starts over at the start page.
+func (m *Model) login(msg tui.LoginMsg) tea.Cmd {
+	sameUser := m.lockedModel != nil && msg.User.Name == m.sharedState.User.Name
+	slog.Info("logged in", "user", msg.User.Name, "role", msg.User.Role, "resumed", sameUser)
+	lockedModel := m.lockedModel
+
+	m.loggedIn = true
+	m.lockedModel = nil
+	m.sharedState.User = msg.User
+
+	if sameUser {
+		m.currentModel = lockedModel
+		return nil
+	}
+
+	if !m.draftChecked {
+		m.draftChecked = true
+		m.loadDraft()
+	}
+
+	m.sharedState.LastPageIndex = tui.LOGIN_PAGE
+	if err := m.changePage(tui.START_PAGE); err != nil {
+		return tui.FatalErrorCmd(err)
+	}
+
+	return m.currentModel.Init()
+}
+
+// loadDraft puts the form left unsaved by the last run in the shared state,
+// for the start page to offer it.
+func (m *Model) loadDraft() {
+	d, ok, err := m.sharedState.Service.Draft(m.sharedState.User)
+	if err != nil {
+		slog.Warn("cannot load draft", "err", err)
+		return
+	}
+	if ok {
+		m.sharedState.Draft = &d
+	}
+}
+
+// changeLockedPage applies a page change that arrives while the login page is
+// shown to the page that is resumed after unlocking.
+func (m *Model) changeLockedPage(to tui.PageIndex) tea.Cmd {
+	if m.lockedModel == nil {
+		return nil
+	}
+
+	loginModel := m.currentModel
+	if err := m.changePage(to); err != nil {
+		return tui.FatalErrorCmd(err)
+	}
+
+	m.lockedModel = m.currentModel
+	m.currentModel = loginModel
+
+	return nil
 }
 
 func (m *Model) changePage(to tui.PageIndex) error {
-	m.sharedState.Error = nil
+	slog.Debug("page changed", "from", m.sharedState.LastPageIndex, "to", to, "user", m.sharedState.User.Name)
+	m.sharedState.Result = nil
 	switch to {
 	case tui.START_PAGE:
 		m.currentModel = view.NewStartPageModel(m.sharedState)
@@ -71,6 +272,24 @@ func (m *Model) changePage(to tui.PageIndex) error {
 	case tui.FORM_PAGE:
 		m.currentModel = view.NewFormPageModel(m.sharedState)
 		return nil
+	case tui.AUDIT_PAGE:
+		m.currentModel = view.NewAuditPageModel(m.sharedState)
+		return nil
+	case tui.LOGIN_PAGE:
+		m.currentModel = view.NewLoginPageModel(m.sharedState, "")
+		return nil
+	case tui.TRASH_PAGE:
+		m.currentModel = view.NewTrashPageModel(m.sharedState)
+		return nil
+	case tui.HISTORY_PAGE:
+		m.currentModel = view.NewHistoryPageModel(m.sharedState)
+		return nil
+	case tui.BACKUP_PAGE:
+		m.currentModel = view.NewBackupPageModel(m.sharedState)
+		return nil
+	case tui.DUPLICATE_PAGE:
+		m.currentModel = view.NewDuplicatePageModel(m.sharedState)
+		return nil
 	}
 
 	return fmt.Errorf("invalid page index %d", to)
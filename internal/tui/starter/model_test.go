@@ -0,0 +1,198 @@
+package starter
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/bgics/pmjay-go/internal/tui/view"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// newTestModel returns a model signed in as an admin on the start page, with
+// its data in a temporary directory.
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+
+	settings := config.App
+	t.Cleanup(func() { config.App = settings })
+	config.App.DataDir = t.TempDir()
+
+	m := NewModel()
+	m.loggedIn = true
+	m.sharedState.User = auth.User{Name: "Asha", Role: auth.RoleAdmin}
+	if err := m.changePage(tui.START_PAGE); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// send updates m with msg and then with the results and finished jobs of
+// the commands that follow, leaving out timers.
+func send(m *Model, msg tea.Msg) tea.Cmd {
+	_, cmd := m.Update(msg)
+	return drain(m, cmd)
+}
+
+// drain runs cmd and feeds the page changes, results and finished jobs it
+// returns back to m. It returns the commands that were not fed back.
+func drain(m *Model, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+
+	msg := cmd()
+	switch msg := msg.(type) {
+	case tea.BatchMsg:
+		var rest []tea.Cmd
+		for _, c := range msg {
+			rest = append(rest, drain(m, c))
+		}
+		return tea.Batch(rest...)
+	case tui.ResultMsg, tui.JobDoneMsg, tui.ChangePageMsg:
+		return send(m, msg)
+	}
+
+	// tea.Sequence returns its commands as a slice of an unexported type
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeFor[tea.Cmd]() {
+		var rest []tea.Cmd
+		for i := range v.Len() {
+			c, _ := v.Index(i).Interface().(tea.Cmd)
+			rest = append(rest, drain(m, c))
+		}
+		return tea.Sequence(rest...)
+	}
+
+	return cmd
+}
+
+// press updates m with each of msgs, leaving out the commands that follow,
+// such as the cursor blink and the delayed draft save.
+func press(m *Model, msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		m.Update(msg)
+	}
+}
+
+func keys(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+func screen(m *Model) string {
+	return ansi.Strip(m.View())
+}
+
+func TestResultShown(t *testing.T) {
+	tests := []struct {
+		msg  tui.ResultMsg
+		want string
+	}{
+		{tui.ResultMsg{Severity: tui.SeverityInfo, Text: "Saved"}, "[INFO] Saved"},
+		{tui.ResultMsg{Severity: tui.SeverityWarn, Text: "Printer is slow"}, "[WARN] Printer is slow"},
+		{tui.ResultMsg{Severity: tui.SeverityError, Err: errors.New("disk full")}, "[ERROR] disk full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg.Severity.String(), func(t *testing.T) {
+			m := newTestModel(t)
+			send(m, tt.msg)
+
+			if got := screen(m); !strings.Contains(got, tt.want) {
+				t.Errorf("view does not show %q:\n%s", tt.want, got)
+			}
+			if m.ExitError != nil {
+				t.Errorf("ExitError = %v, want nil", m.ExitError)
+			}
+		})
+	}
+}
+
+func TestFatalResultQuits(t *testing.T) {
+	m := newTestModel(t)
+	err := errors.New("data file is damaged")
+
+	_, cmd := m.Update(tui.ResultMsg{Severity: tui.SeverityFatal, Err: err})
+
+	if cmd == nil {
+		t.Fatal("no command returned, want quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("command does not quit")
+	}
+	if !errors.Is(m.ExitError, err) {
+		t.Errorf("ExitError = %v, want %v", m.ExitError, err)
+	}
+}
+
+func TestFailedJobShown(t *testing.T) {
+	m := newTestModel(t)
+
+	cmd := m.sharedState.Jobs.Start("Printing", "Printed", "Ravi Kumar", func() error {
+		return errors.New("printer offline")
+	})
+	drain(m, cmd)
+
+	want := "[ERROR] Printing failed for Ravi Kumar: printer offline"
+	if got := screen(m); !strings.Contains(got, want) {
+		t.Errorf("view does not show %q:\n%s", want, got)
+	}
+	if m.sharedState.Jobs.Running() {
+		t.Error("failed job is still running")
+	}
+}
+
+func TestDismissResult(t *testing.T) {
+	m := newTestModel(t)
+	send(m, tui.ResultMsg{Severity: tui.SeverityError, Err: errors.New("disk full")})
+
+	if got := screen(m); !strings.Contains(got, "ctrl+x dismiss") {
+		t.Fatalf("view does not show the result:\n%s", got)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+
+	if m.sharedState.Result != nil {
+		t.Errorf("Result = %v after ctrl+x, want nil", m.sharedState.Result)
+	}
+	if got := screen(m); strings.Contains(got, "disk full") {
+		t.Errorf("view still shows the result after ctrl+x:\n%s", got)
+	}
+}
+
+func TestFailedSaveShown(t *testing.T) {
+	m := newTestModel(t)
+
+	// the records cannot be read, so saving fails
+	if err := os.Mkdir(config.App.DataFile(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := m.currentModel.(*view.FormPageModel); !ok {
+		t.Fatalf("page is %T after enter on New Patient, want the form", m.currentModel)
+	}
+
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	press(m, keys("Sita Devi"), tab, keys("Rampur"))
+	// past the rest of the address to the diagnosis, then on to SAVE
+	press(m, tab, tab, tab, tab, tab, keys("fever"))
+	for range 8 {
+		press(m, tab)
+	}
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	want := "[ERROR] Saving failed for Sita Devi"
+	if got := screen(m); !strings.Contains(got, want) {
+		t.Errorf("view does not show %q:\n%s", want, got)
+	}
+	if _, ok := m.currentModel.(*view.StartPageModel); !ok {
+		t.Errorf("page is %T after saving, want the start page", m.currentModel)
+	}
+}
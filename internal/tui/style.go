@@ -5,6 +5,8 @@ import "github.com/charmbracelet/lipgloss"
 var (
 	InactiveColor            = lipgloss.Color("240")
 	ErrorColor               = lipgloss.Color("202")
+	InfoColor                = lipgloss.Color("36")
+	WarnColor                = lipgloss.Color("214")
 	DatePickerHighlightColor = lipgloss.Color("208")
 
 	BorderStyle = lipgloss.NormalBorder()
@@ -63,4 +65,15 @@ var (
 			Foreground(ErrorColor).
 			MarginTop(2).
 			MarginLeft(2)
+
+	ToastStyle = ErrStyle.
+			Foreground(InfoColor)
+
+	ResultStyle = lipgloss.NewStyle().
+			MarginTop(2).
+			MarginLeft(2)
+
+	StatusBarStyle = lipgloss.NewStyle().
+			MarginTop(1).
+			MarginLeft(2)
 )
@@ -0,0 +1,234 @@
+package view
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/model"
+	"github.com/bgics/pmjay-go/pincode"
+	"github.com/bgics/pmjay-go/service"
+	"github.com/bgics/pmjay-go/validate"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the parts of an address, in the order they are filled in
+const (
+	linePart = iota
+	blockPart
+	districtPart
+	statePart
+	pinPart
+	addressParts
+)
+
+// searchFields are what each part is looked up by in the pincode directory.
+var searchFields = [addressParts]pincode.Field{pincode.Office, pincode.Block, pincode.District, pincode.State, pincode.PIN}
+
+// addressFields are the inputs of the parts of an address. As a part is
+// typed the matching places of the pincode directory are offered, and
+// picking one fills in the parts above it.
+type addressFields struct {
+	inputs [addressParts]textinput.Model
+	hits   []pincode.Entry
+	list   dropdown
+}
+
+func newAddressFields() addressFields {
+	var a addressFields
+
+	a.inputs[linePart] = makeTextInput(false, config.ADDRESS1, config.ADDRESS2, config.ADDRESS3)
+	a.inputs[linePart].Placeholder = "house, village or locality"
+	for _, part := range []int{blockPart, districtPart, statePart} {
+		a.inputs[part] = makeTextInput(false)
+		a.inputs[part].Width = 18
+	}
+	a.inputs[pinPart] = makeTextInput(false)
+	a.inputs[pinPart].Width = 7
+	a.inputs[pinPart].CharLimit = 6
+
+	return a
+}
+
+// set shows address, which is put in the line whole if it has no parts.
+func (a *addressFields) set(address string, parts model.Address) {
+	if parts == (model.Address{}) {
+		parts.Line = address
+	}
+
+	a.inputs[linePart].SetValue(parts.Line)
+	a.inputs[blockPart].SetValue(parts.Block)
+	a.inputs[districtPart].SetValue(parts.District)
+	a.inputs[statePart].SetValue(parts.State)
+	a.inputs[pinPart].SetValue(parts.PIN)
+}
+
+func (a *addressFields) value() model.Address {
+	return model.Address{
+		Line:     strings.TrimSpace(a.inputs[linePart].Value()),
+		Block:    strings.TrimSpace(a.inputs[blockPart].Value()),
+		District: strings.TrimSpace(a.inputs[districtPart].Value()),
+		State:    strings.TrimSpace(a.inputs[statePart].Value()),
+		PIN:      strings.TrimSpace(a.inputs[pinPart].Value()),
+	}
+}
+
+func (a *addressFields) focus(part int) tea.Cmd {
+	return a.inputs[part].Focus()
+}
+
+func (a *addressFields) blur() {
+	for i := range a.inputs {
+		a.inputs[i].Blur()
+	}
+	a.list.close()
+}
+
+// update passes msg to the inputs and looks up the focused part if it
+// changed.
+func (a *addressFields) update(msg tea.Msg, part int, svc *service.Service) (tea.Cmd, error) {
+	var before string
+	if part >= 0 {
+		before = a.inputs[part].Value()
+	}
+
+	cmds := make([]tea.Cmd, addressParts)
+	for i := range a.inputs {
+		a.inputs[i], cmds[i] = a.inputs[i].Update(msg)
+	}
+
+	var err error
+	if part >= 0 && a.inputs[part].Value() != before {
+		err = a.search(part, svc)
+	}
+
+	return tea.Batch(cmds...), err
+}
+
+// search offers the places matching what is typed in part.
+func (a *addressFields) search(part int, svc *service.Service) error {
+	a.hits = nil
+	a.list.close()
+
+	query := a.inputs[part].Value()
+	minChars := minSuggestionChars
+	switch part {
+	case linePart:
+		// the village is typed after the house
+		query = lastSegment(query)
+	case pinPart:
+		minChars = 3
+	}
+	if len([]rune(strings.TrimSpace(query))) < minChars {
+		return nil
+	}
+
+	within := pincode.Entry{District: a.value().District, State: a.value().State}
+	if pin := a.value().PIN; len(pin) == 6 {
+		within.PIN = pin
+	}
+
+	hits, err := svc.SearchAddresses(searchFields[part], query, within, maxSuggestions)
+	if err != nil {
+		return fmt.Errorf("cannot search the pincode directory: %w", err)
+	}
+
+	a.hits = hits
+	lines := make([]string, len(hits))
+	for i, hit := range hits {
+		lines[i] = model.Address{Line: hit.Office, Block: hit.Block, District: hit.District, State: hit.State, PIN: hit.PIN}.String()
+	}
+	a.list = newDropdown(lines)
+
+	return nil
+}
+
+// handleKey moves through and picks from the places offered for part.
+func (a *addressFields) handleKey(part int, key string) (handled bool) {
+	if !a.list.isOpen() {
+		return false
+	}
+
+	picked, handled := a.list.handleKey(key)
+	if picked >= 0 {
+		a.pick(part, a.hits[picked])
+	}
+	return handled
+}
+
+// pick fills in hit for part and the parts above it.
+func (a *addressFields) pick(part int, hit pincode.Entry) {
+	set := func(part int, value string) {
+		a.inputs[part].SetValue(value)
+		a.inputs[part].CursorEnd()
+	}
+
+	switch part {
+	case linePart:
+		line := a.inputs[linePart].Value()
+		set(linePart, strings.TrimSuffix(line, lastSegment(line))+hit.Office)
+	case pinPart:
+		if strings.TrimSpace(a.inputs[linePart].Value()) == "" {
+			set(linePart, hit.Office)
+		}
+	}
+
+	if part == linePart || part == pinPart {
+		set(pinPart, hit.PIN)
+	}
+	if part <= blockPart || part == pinPart {
+		set(blockPart, hit.Block)
+	}
+	if part <= districtPart || part == pinPart {
+		set(districtPart, hit.District)
+	}
+	set(statePart, hit.State)
+
+	a.hits = nil
+}
+
+// lastSegment is the text after the last comma of line.
+func lastSegment(line string) string {
+	if i := strings.LastIndexByte(line, ','); i >= 0 {
+		return strings.TrimLeft(line[i+1:], " ")
+	}
+	return line
+}
+
+// isAddressField reports whether the field at index is a part of the
+// address, and which.
+func isAddressField(index int) (part int, ok bool) {
+	part = index - addressIndex
+	return part, 0 <= part && part < addressParts
+}
+
+// renderAddress renders the parts in three rows, with the places offered
+// under the row of the focused one.
+func (m *FormPageModel) renderAddress() string {
+	a := &m.address
+	field := func(name string, part int, problems ...validate.Field) string {
+		return m.renderTextField(name, a.inputs[part].View(), "", addressIndex+part, problems...)
+	}
+
+	rows := []string{
+		field("ADDRESS", linePart, validate.Address),
+		lipgloss.JoinHorizontal(lipgloss.Top, field("BLOCK", blockPart), field("DISTRICT", districtPart)),
+		lipgloss.JoinHorizontal(lipgloss.Top, field("STATE", statePart), field("PIN", pinPart, validate.PIN)),
+	}
+
+	if part, ok := isAddressField(m.fieldIndex); ok && a.list.isOpen() {
+		row := 0
+		switch part {
+		case blockPart, districtPart:
+			row = 1
+		case statePart, pinPart:
+			row = 2
+		}
+		rows = slices.Insert(rows, row+1, a.list.View(m.sharedState.Zones, addressZone))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
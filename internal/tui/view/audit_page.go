@@ -0,0 +1,180 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	auditPatientIndex = iota
+	auditFromIndex
+	auditToIndex
+)
+
+const maxAuditRows = 15
+
+type AuditPageModel struct {
+	patientInput textinput.Model
+	fromInput    textinput.Model
+	toInput      textinput.Model
+	fieldIndex   int
+
+	entries  []audit.Entry
+	queryErr error
+
+	sharedState *tui.SharedState
+}
+
+func NewAuditPageModel(sharedState *tui.SharedState) *AuditPageModel {
+	m := &AuditPageModel{}
+
+	m.patientInput = makeTextInput(true, config.NAME)
+	m.fromInput = makeTextInput(false)
+	m.fromInput.CharLimit = len(config.App.DateFormat)
+	m.fromInput.Placeholder = config.App.DateFormat
+	m.toInput = makeTextInput(false)
+	m.toInput.CharLimit = len(config.App.DateFormat)
+	m.toInput.Placeholder = config.App.DateFormat
+
+	m.sharedState = sharedState
+
+	m.runQuery()
+
+	return m
+}
+
+func (m *AuditPageModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *AuditPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.sharedState.LastPageIndex = tui.AUDIT_PAGE
+			return m, tui.ChangePageCmd(tui.START_PAGE)
+		case "tab", "down":
+			m.fieldIndex = cyclicAdjust(m.fieldIndex+1, auditPatientIndex, auditToIndex)
+			return m, m.updateFocus()
+		case "shift+tab", "up":
+			m.fieldIndex = cyclicAdjust(m.fieldIndex-1, auditPatientIndex, auditToIndex)
+			return m, m.updateFocus()
+		}
+	}
+
+	cmd := make([]tea.Cmd, 3)
+
+	m.patientInput, cmd[0] = m.patientInput.Update(msg)
+	m.fromInput, cmd[1] = m.fromInput.Update(msg)
+	m.toInput, cmd[2] = m.toInput.Update(msg)
+
+	m.runQuery()
+
+	return m, tea.Batch(cmd...)
+}
+
+func (m *AuditPageModel) View() string {
+	inputs := lipgloss.JoinVertical(
+		lipgloss.Left,
+		makeTextField("PATIENT", m.patientInput.View(), m.fieldIndex == auditPatientIndex),
+		makeTextField("FROM", m.fromInput.View(), m.fieldIndex == auditFromIndex),
+		makeTextField("TO", m.toInput.View(), m.fieldIndex == auditToIndex),
+	)
+
+	rows := []string{inputs}
+
+	for i, e := range m.entries {
+		if i == maxAuditRows {
+			rows = append(rows, lipgloss.NewStyle().
+				Foreground(tui.InactiveColor).
+				Render(fmt.Sprintf("  ... %d more", len(m.entries)-maxAuditRows)))
+			break
+		}
+		rows = append(rows, renderAuditEntry(e))
+	}
+
+	if len(m.entries) > 0 {
+		rows[1] = lipgloss.NewStyle().MarginTop(1).Render(rows[1])
+	}
+
+	if m.queryErr != nil {
+		rows = append(rows, tui.RenderError(m.queryErr))
+	} else if m.sharedState.Result != nil {
+		rows = append(rows, tui.RenderResult(m.sharedState.Result))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(2).
+		MarginLeft(1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func renderAuditEntry(e audit.Entry) string {
+	line := fmt.Sprintf(
+		"  %s  %-12s %-6s %-30s %s",
+		e.Time.Format(config.App.DateFormat+" 15:04"),
+		trimRunes(e.Operator, 12),
+		e.Action,
+		trimRunes(e.PatientName, 30),
+		e.Outcome,
+	)
+
+	if len(e.Dates) > 0 {
+		line += "  [" + strings.Join(e.Dates, " ") + "]"
+	}
+
+	if e.Outcome != audit.OutcomeOK {
+		return lipgloss.NewStyle().Foreground(tui.ErrorColor).Render(line)
+	}
+	return line
+}
+
+func (m *AuditPageModel) runQuery() {
+	filter := audit.Filter{Patient: m.patientInput.Value()}
+
+	var err error
+	if filter.From, err = parseOptionalDate(m.fromInput.Value()); err != nil {
+		m.queryErr = fmt.Errorf("invalid from date, expected %s", config.App.DateFormat)
+		return
+	}
+	if filter.To, err = parseOptionalDate(m.toInput.Value()); err != nil {
+		m.queryErr = fmt.Errorf("invalid to date, expected %s", config.App.DateFormat)
+		return
+	}
+
+	m.entries, m.queryErr = m.sharedState.Service.QueryAudit(m.sharedState.User, filter)
+}
+
+func (m *AuditPageModel) updateFocus() tea.Cmd {
+	m.patientInput.Blur()
+	m.fromInput.Blur()
+	m.toInput.Blur()
+
+	switch m.fieldIndex {
+	case auditPatientIndex:
+		return m.patientInput.Focus()
+	case auditFromIndex:
+		return m.fromInput.Focus()
+	case auditToIndex:
+		return m.toInput.Focus()
+	}
+
+	return nil
+}
+
+func parseOptionalDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(config.App.DateFormat, value)
+}
@@ -0,0 +1,228 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/backup"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/bgics/pmjay-go/service"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxBackupRows = 10
+
+type BackupPageModel struct {
+	backups     []backup.Backup
+	backupIndex int
+
+	diff    service.BackupDiff
+	diffErr error
+
+	loadErr     error
+	dialog      *dialogModel
+	sharedState *tui.SharedState
+}
+
+func NewBackupPageModel(sharedState *tui.SharedState) *BackupPageModel {
+	m := &BackupPageModel{sharedState: sharedState}
+	m.loadErr = m.loadBackups()
+
+	return m
+}
+
+func (m *BackupPageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *BackupPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "shift+tab":
+			m.backupIndex = cyclicAdjust(m.backupIndex-1, 0, max(len(m.backups)-1, 0))
+			m.updateDiff()
+		case "down", "tab":
+			m.backupIndex = cyclicAdjust(m.backupIndex+1, 0, max(len(m.backups)-1, 0))
+			m.updateDiff()
+		case "b":
+			return m.handleCreate()
+		case "r":
+			return m.confirmRestore()
+		case "esc":
+			m.sharedState.LastPageIndex = tui.BACKUP_PAGE
+			return m, tui.ChangePageCmd(tui.START_PAGE)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *BackupPageModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
+	rows := []string{"BACKUPS  " + config.App.BackupDir()}
+
+	if len(m.backups) == 0 && m.loadErr == nil {
+		rows = append(rows, lipgloss.NewStyle().
+			MarginTop(1).
+			Foreground(tui.InactiveColor).
+			Render("  no backups yet"))
+	}
+
+	start := max(0, min(m.backupIndex-maxBackupRows/2, len(m.backups)-maxBackupRows))
+	for i := start; i < min(start+maxBackupRows, len(m.backups)); i++ {
+		b := m.backups[i]
+
+		status := "ok"
+		if err := backup.Verify(b); err != nil {
+			status = "damaged"
+		}
+
+		line := fmt.Sprintf("%s  %8d bytes  %s", b.Time.Format(config.App.DateFormat+" 15:04:05"), b.Size, status)
+		if i == m.backupIndex {
+			rows = append(rows, "> "+line)
+		} else {
+			rows = append(rows, lipgloss.NewStyle().Foreground(tui.InactiveColor).Render("  "+line))
+		}
+	}
+
+	if len(m.backups) > 0 {
+		rows[1] = lipgloss.NewStyle().MarginTop(1).Render(rows[1])
+		rows = append(rows, m.renderDiff())
+	}
+
+	rows = append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("b back up now • r restore selected • esc back"))
+
+	if m.loadErr != nil {
+		rows = append(rows, tui.RenderError(m.loadErr))
+	} else if m.sharedState.Result != nil {
+		rows = append(rows, tui.RenderResult(m.sharedState.Result))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		MarginLeft(3).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m *BackupPageModel) renderDiff() string {
+	var lines []string
+
+	switch {
+	case m.diffErr != nil:
+		lines = append(lines, lipgloss.NewStyle().Foreground(tui.ErrorColor).Render(m.diffErr.Error()))
+	case m.diff.IsEmpty():
+		lines = append(lines, "matches the current data")
+	default:
+		lines = append(lines, "restoring would")
+		for _, record := range m.diff.Added {
+			lines = append(lines, "  bring back "+record.Name)
+		}
+		for _, record := range m.diff.Removed {
+			lines = append(lines, "  remove "+record.Name)
+		}
+		for _, rc := range m.diff.Changed {
+			lines = append(lines, "  change "+rc.Record.Name)
+			for _, change := range rc.Changes {
+				lines = append(lines, fmt.Sprintf("    %s: %s → %s", change.Field, emptyAsDash(change.Old), emptyAsDash(change.New)))
+			}
+		}
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		Border(tui.BorderStyle).
+		BorderForeground(tui.InactiveColor).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m *BackupPageModel) handleCreate() (tea.Model, tea.Cmd) {
+	b, err := m.sharedState.Service.CreateBackup(m.sharedState.User)
+	if err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	m.backupIndex = 0
+
+	return m, tea.Batch(tui.InfoCmd("backed up to "+b.Name), m.reload())
+}
+
+func (m *BackupPageModel) confirmRestore() (tea.Model, tea.Cmd) {
+	if len(m.backups) == 0 {
+		return m, nil
+	}
+
+	b := m.backups[m.backupIndex]
+	m.dialog = newConfirmDialog(
+		"Restore backup",
+		fmt.Sprintf("Replace the current records with the backup from %s? The current data is backed up first.",
+			b.Time.Format(config.App.DateFormat+" 15:04:05")),
+		"RESTORE",
+		func() tea.Cmd {
+			_, cmd := m.handleRestore()
+			return cmd
+		},
+	)
+
+	return m, nil
+}
+
+func (m *BackupPageModel) handleRestore() (tea.Model, tea.Cmd) {
+	if len(m.backups) == 0 {
+		return m, nil
+	}
+
+	b := m.backups[m.backupIndex]
+	if err := m.sharedState.Service.RestoreBackup(m.sharedState.User, b); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	m.backupIndex = 0
+
+	return m, tea.Batch(tui.InfoCmd(fmt.Sprintf("restored %s, the previous data was backed up first", b.Name)), m.reload())
+}
+
+func (m *BackupPageModel) reload() tea.Cmd {
+	if err := m.loadBackups(); err != nil {
+		return tui.ErrorCmd(err)
+	}
+	return nil
+}
+
+func (m *BackupPageModel) loadBackups() error {
+	backups, err := m.sharedState.Service.ListBackups(m.sharedState.User)
+	if err != nil {
+		return err
+	}
+
+	m.backups = backups
+	m.backupIndex = min(m.backupIndex, max(len(m.backups)-1, 0))
+	m.updateDiff()
+
+	return nil
+}
+
+func (m *BackupPageModel) updateDiff() {
+	if len(m.backups) == 0 {
+		m.diff, m.diffErr = service.BackupDiff{}, nil
+		return
+	}
+
+	m.diff, m.diffErr = m.sharedState.Service.DiffBackup(m.sharedState.User, m.backups[m.backupIndex])
+}
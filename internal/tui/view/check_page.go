@@ -0,0 +1,83 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/doctor"
+	"github.com/bgics/pmjay-go/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	checkNameStyle = lipgloss.NewStyle().Width(13)
+	checkFixStyle  = lipgloss.NewStyle().MarginLeft(19).Foreground(tui.InactiveColor)
+)
+
+// CheckPageModel shows the startup checks that did not pass. The app goes on
+// to next once they have been read.
+type CheckPageModel struct {
+	results []doctor.Result
+	next    tea.Model
+}
+
+func NewCheckPageModel(results []doctor.Result, next tea.Model) *CheckPageModel {
+	return &CheckPageModel{results: results, next: next}
+}
+
+func (m *CheckPageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *CheckPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			return m.next, m.next.Init()
+		case "r":
+			m.results = doctor.Run()
+			if doctor.Worst(m.results) == doctor.StatusOK {
+				return m.next, m.next.Init()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *CheckPageModel) View() string {
+	title := "Some startup checks did not pass, printing may not work"
+	if doctor.Worst(m.results) == doctor.StatusWarn {
+		title = "Startup checks passed with warnings"
+	}
+
+	rows := []string{lipgloss.NewStyle().MarginBottom(1).Render(title)}
+
+	for _, r := range m.results {
+		status := lipgloss.NewStyle().Width(6)
+		switch r.Status {
+		case doctor.StatusOK:
+			status = status.Foreground(tui.InfoColor)
+		case doctor.StatusWarn:
+			status = status.Foreground(tui.DatePickerHighlightColor)
+		case doctor.StatusFail:
+			status = status.Foreground(tui.ErrorColor)
+		}
+
+		rows = append(rows, status.Render(r.Status.String())+checkNameStyle.Render(r.Name)+r.Detail)
+		if r.Fix != "" {
+			rows = append(rows, checkFixStyle.Render(fmt.Sprintf("fix: %s", r.Fix)))
+		}
+	}
+
+	rows = append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("enter continue • r check again • ctrl+c quit"))
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		MarginLeft(3).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/bgics/pmjay-go/internal/tui"
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	datepicker "github.com/ethanefung/bubble-datepicker"
 )
 
@@ -28,12 +29,25 @@ func makeTextInput(focus bool, cfgKeys ...config.FieldName) textinput.Model {
 		maxChars += config.FieldConfig[key].MaxChars
 	}
 
-	t.CharLimit = maxChars
+	// longer text can be typed but is flagged, as it is cut off when printed
+	t.CharLimit = 2 * maxChars
 
 	return t
 }
 
-func makeDateInput() datepicker.Model {
+// makeDateInput makes a text input for a date field, see parseDateInput for
+// what can be typed in it.
+func makeDateInput() textinput.Model {
+	t := textinput.New()
+	t.Cursor.SetMode(cursor.CursorBlink)
+	t.Prompt = ""
+	t.Placeholder = config.App.DateFormat
+	t.CharLimit = max(len(config.App.DateFormat), 6)
+
+	return t
+}
+
+func makeDatePicker() datepicker.Model {
 	d := datepicker.New(time.Now())
 	defaultStyle := datepicker.DefaultStyles()
 
@@ -44,3 +58,21 @@ func makeDateInput() datepicker.Model {
 
 	return d
 }
+
+// isInput reports whether msg is a key press or a mouse event, which an open
+// dialog takes before the page.
+func isInput(msg tea.Msg) bool {
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		return true
+	}
+	return false
+}
+
+func trimRunes(str string, max int) string {
+	runes := []rune(str)
+	if len(runes) > max {
+		return string(runes[:max])
+	}
+	return str
+}
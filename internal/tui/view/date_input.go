@@ -0,0 +1,84 @@
+package view
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bgics/pmjay-go/config"
+)
+
+var (
+	relativeDayPattern = regexp.MustCompile(`^[+-]\d+$`)
+	agePattern         = regexp.MustCompile(`^(\d+)([dwmy])$`)
+)
+
+// today is the current day, at midnight UTC like the dates read from the
+// data file, so dates entered on the same day compare equal.
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseDateInput reads a date typed into a date field. Besides a date in
+// config.App.DateFormat it takes
+//
+//	t       today
+//	y       yesterday
+//	+3, -2  days from today
+//
+// and, when age is true, an age on date such as 5d, 2w, 3m or 1y, which
+// gives the date of birth. Every unit counts the day of birth as day 1, as
+// the printed sheet does, so 7d and 1w give the same date and both print
+// as 7 days.
+func parseDateInput(value string, date time.Time, age bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	shortcut := strings.ToLower(value)
+
+	switch {
+	case shortcut == "":
+		return time.Time{}, fmt.Errorf("enter a date as %s", config.App.DateFormat)
+	case shortcut == "t":
+		return today(), nil
+	case shortcut == "y":
+		return today().AddDate(0, 0, -1), nil
+	case relativeDayPattern.MatchString(value):
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid number of days %q", value)
+		}
+		return today().AddDate(0, 0, days), nil
+	}
+
+	if match := agePattern.FindStringSubmatch(shortcut); match != nil {
+		if !age {
+			return time.Time{}, fmt.Errorf("an age only gives a date of birth")
+		}
+
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 {
+			return time.Time{}, fmt.Errorf("invalid age %q", value)
+		}
+
+		var born time.Time
+		switch match[2] {
+		case "d":
+			born = date.AddDate(0, 0, -n)
+		case "w":
+			born = date.AddDate(0, 0, -7*n)
+		case "m":
+			born = date.AddDate(0, -n, 0)
+		default:
+			born = date.AddDate(-n, 0, 0)
+		}
+		return born.AddDate(0, 0, 1), nil
+	}
+
+	parsed, err := time.Parse(config.App.DateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date, expected %s", config.App.DateFormat)
+	}
+	return parsed, nil
+}
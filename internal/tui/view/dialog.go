@@ -0,0 +1,144 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bgics/pmjay-go/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	dialogStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(tui.DatePickerHighlightColor).
+			Padding(1, 2).
+			MarginTop(2).
+			MarginLeft(4).
+			Width(68)
+
+	dialogTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				MarginBottom(1)
+)
+
+// dialogOption is one answer to a dialog, picked with its key or by moving
+// to it and pressing enter.
+type dialogOption struct {
+	label  string
+	key    string
+	action func() tea.Cmd
+}
+
+var cancelOption = dialogOption{label: "CANCEL", key: "n"}
+
+// dialogModel asks the operator to confirm an action or pick between a few
+// before anything is done. A page that opens one passes its key presses to
+// it until it closes and shows it in place of the page.
+type dialogModel struct {
+	title   string
+	message string
+	// options start with cancel, which has the focus to begin with so enter
+	// alone never does anything
+	options     []dialogOption
+	optionIndex int
+	// onCancel is run when the dialog is cancelled, if set
+	onCancel func() tea.Cmd
+}
+
+// newConfirmDialog asks whether to do onConfirm.
+func newConfirmDialog(title, message, confirmLabel string, onConfirm func() tea.Cmd) *dialogModel {
+	return newChoiceDialog(title, message, dialogOption{label: confirmLabel, key: "y", action: onConfirm})
+}
+
+// newChoiceDialog asks which of options to do, if any.
+func newChoiceDialog(title, message string, options ...dialogOption) *dialogModel {
+	return &dialogModel{
+		title:   title,
+		message: message,
+		options: append([]dialogOption{cancelOption}, options...),
+	}
+}
+
+// Update handles a key press or a click on an option. closed is true once
+// the dialog is answered, cmd is the result of the picked action.
+func (d *dialogModel) Update(msg tea.Msg, zones *tui.Zones) (cmd tea.Cmd, closed bool) {
+	if msg, ok := msg.(tea.MouseMsg); ok {
+		for i := range d.options {
+			if zones.Hit(dialogOptionZone(i), msg) {
+				return d.pick(i), true
+			}
+		}
+		return nil, false
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil, false
+	}
+
+	switch keyMsg.String() {
+	case "left", "shift+tab":
+		d.optionIndex = cyclicAdjust(d.optionIndex-1, 0, len(d.options)-1)
+	case "right", "tab":
+		d.optionIndex = cyclicAdjust(d.optionIndex+1, 0, len(d.options)-1)
+	case "esc":
+		return d.pick(0), true
+	case "enter":
+		return d.pick(d.optionIndex), true
+	default:
+		for i, option := range d.options {
+			if keyMsg.String() == option.key {
+				return d.pick(i), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (d *dialogModel) pick(index int) tea.Cmd {
+	action := d.options[index].action
+	if index == 0 {
+		action = d.onCancel
+	}
+
+	if action == nil {
+		return nil
+	}
+	return action()
+}
+
+func dialogOptionZone(index int) string {
+	return fmt.Sprintf("dialog.option.%d", index)
+}
+
+func (d *dialogModel) View(zones *tui.Zones) string {
+	var buttons, hints []string
+	for i, option := range d.options {
+		style := tui.BtnInactiveStyle
+		if i == d.optionIndex {
+			style = tui.BtnActiveStyle
+		}
+		buttons = append(buttons, zones.Mark(dialogOptionZone(i), style.Render(option.label)))
+
+		key := option.key
+		if i == 0 {
+			key += "/esc"
+		}
+		hints = append(hints, fmt.Sprintf("%s %s", key, strings.ToLower(option.label)))
+	}
+	hints = append(hints, "←/→ choose", "enter select")
+
+	return dialogStyle.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		dialogTitleStyle.Render(d.title),
+		d.message,
+		lipgloss.JoinHorizontal(lipgloss.Left, buttons...),
+		lipgloss.NewStyle().
+			MarginTop(1).
+			Foreground(tui.InactiveColor).
+			Render(strings.Join(hints, " • ")),
+	))
+}
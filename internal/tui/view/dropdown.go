@@ -0,0 +1,96 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dropdown lists the matches for what is typed in an input, under it. Up and
+// down move through the list, enter or a click picks a line and esc closes
+// it.
+type dropdown struct {
+	lines []string
+	// index is the highlighted line, -1 before one is
+	index int
+}
+
+func newDropdown(lines []string) dropdown {
+	return dropdown{lines: lines, index: -1}
+}
+
+func (d *dropdown) isOpen() bool {
+	return len(d.lines) > 0
+}
+
+func (d *dropdown) close() {
+	*d = newDropdown(nil)
+}
+
+// handleKey returns the index of the line picked, -1 if none was. handled is
+// false for keys left to the page, such as up on the first line.
+func (d *dropdown) handleKey(key string) (picked int, handled bool) {
+	switch key {
+	case "down":
+		d.index = min(d.index+1, len(d.lines)-1)
+	case "up":
+		if d.index < 0 {
+			return -1, false
+		}
+		d.index--
+	case "enter":
+		if d.index < 0 {
+			return -1, false
+		}
+		picked = d.index
+		d.close()
+		return picked, true
+	case "esc":
+		d.close()
+	default:
+		return -1, false
+	}
+
+	return -1, true
+}
+
+// click returns the index of the line msg is a click on, -1 if none. The
+// lines are the zones marked by View with zoneID.
+func (d *dropdown) click(zones *tui.Zones, zoneID string, msg tea.MouseMsg) int {
+	for i := range d.lines {
+		if zones.Hit(dropdownLineZone(zoneID, i), msg) {
+			d.close()
+			return i
+		}
+	}
+	return -1
+}
+
+func dropdownLineZone(zoneID string, index int) string {
+	return fmt.Sprintf("%s.%d", zoneID, index)
+}
+
+func (d *dropdown) View(zones *tui.Zones, zoneID string) string {
+	if !d.isOpen() {
+		return ""
+	}
+
+	var lines []string
+	for i, line := range d.lines {
+		if i == d.index {
+			line = lipgloss.NewStyle().Foreground(tui.DatePickerHighlightColor).Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, zones.Mark(dropdownLineZone(zoneID, i), line))
+	}
+	lines = append(lines, lipgloss.NewStyle().
+		Foreground(tui.InactiveColor).
+		Render("  ↑/↓ choose • enter pick • esc close"))
+
+	return lipgloss.NewStyle().
+		MarginLeft(tui.FieldNameActiveStyle.GetWidth()).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,280 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/duplicate"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/bgics/pmjay-go/model"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mergeValueWidth is how much of a field value of either record is shown
+const mergeValueWidth = 28
+
+// merge sides, which record a field is picked from
+const (
+	leftSide = iota
+	rightSide
+)
+
+type DuplicatePageModel struct {
+	pairs     []duplicate.Pair
+	pairIndex int
+	loadErr   error
+
+	// merging is set while the fields of the pair at pairIndex are picked.
+	// picks holds the side of each of duplicate.Fields, then of the record
+	// kept.
+	merging  bool
+	picks    []int
+	rowIndex int
+
+	dialog      *dialogModel
+	sharedState *tui.SharedState
+}
+
+func NewDuplicatePageModel(sharedState *tui.SharedState) *DuplicatePageModel {
+	m := &DuplicatePageModel{sharedState: sharedState}
+	m.loadErr = m.loadPairs()
+
+	return m
+}
+
+func (m *DuplicatePageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *DuplicatePageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.merging {
+		return m.updateMerge(keyMsg)
+	}
+
+	switch keyMsg.String() {
+	case "up", "shift+tab":
+		m.pairIndex = cyclicAdjust(m.pairIndex-1, 0, max(len(m.pairs)-1, 0))
+	case "down", "tab":
+		m.pairIndex = cyclicAdjust(m.pairIndex+1, 0, max(len(m.pairs)-1, 0))
+	case "enter":
+		if len(m.pairs) > 0 {
+			m.startMerge()
+		}
+	case "esc":
+		m.sharedState.LastPageIndex = tui.DUPLICATE_PAGE
+		return m, tui.ChangePageCmd(tui.START_PAGE)
+	}
+
+	return m, nil
+}
+
+func (m *DuplicatePageModel) updateMerge(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "shift+tab":
+		m.rowIndex = cyclicAdjust(m.rowIndex-1, 0, len(m.picks)-1)
+	case "down", "tab":
+		m.rowIndex = cyclicAdjust(m.rowIndex+1, 0, len(m.picks)-1)
+	case "left":
+		m.picks[m.rowIndex] = leftSide
+	case "right":
+		m.picks[m.rowIndex] = rightSide
+	case "enter":
+		return m.confirmMerge()
+	case "esc":
+		m.merging = false
+	}
+
+	return m, nil
+}
+
+// startMerge picks every field from the record updated last, which is kept.
+func (m *DuplicatePageModel) startMerge() {
+	pair := m.pairs[m.pairIndex]
+
+	side := leftSide
+	if pair.B.UpdatedAt.After(pair.A.UpdatedAt) {
+		side = rightSide
+	}
+
+	m.picks = make([]int, len(duplicate.Fields)+1)
+	for i := range m.picks {
+		m.picks[i] = side
+	}
+	m.rowIndex = 0
+	m.merging = true
+}
+
+// merged is the record kept with the picked fields, and the one retired.
+func (m *DuplicatePageModel) merged() (model.FormData, model.FormData) {
+	pair := m.pairs[m.pairIndex]
+	sides := [2]model.FormData{pair.A, pair.B}
+
+	keepSide := m.picks[len(duplicate.Fields)]
+	kept := sides[keepSide]
+	for i, field := range duplicate.Fields {
+		field.Take(&kept, sides[m.picks[i]])
+	}
+
+	return kept, sides[1-keepSide]
+}
+
+func (m *DuplicatePageModel) confirmMerge() (tea.Model, tea.Cmd) {
+	kept, retired := m.merged()
+
+	m.dialog = newConfirmDialog(
+		"Merge records",
+		fmt.Sprintf("Merge %s into %s? %s moves to the trash, noting the record it was merged into.",
+			retired.Name, kept.Name, retired.Name),
+		"MERGE",
+		func() tea.Cmd {
+			return m.handleMerge(kept, retired)
+		},
+	)
+
+	return m, nil
+}
+
+func (m *DuplicatePageModel) handleMerge(kept, retired model.FormData) tea.Cmd {
+	record, err := m.sharedState.Service.MergeRecords(m.sharedState.User, kept, retired)
+	if err != nil {
+		return tui.ErrorCmd(err)
+	}
+
+	m.merging = false
+	if err := m.loadPairs(); err != nil {
+		return tui.ErrorCmd(err)
+	}
+
+	return tui.InfoCmd(fmt.Sprintf("Merged %s into %s", retired.Name, record.Name))
+}
+
+func (m *DuplicatePageModel) loadPairs() error {
+	pairs, err := m.sharedState.Service.FindDuplicates(m.sharedState.User)
+	if err != nil {
+		return err
+	}
+
+	m.pairs = pairs
+	m.pairIndex = min(m.pairIndex, max(len(m.pairs)-1, 0))
+
+	return nil
+}
+
+func (m *DuplicatePageModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
+	var rows []string
+	if m.merging {
+		rows = m.mergeRows()
+	} else {
+		rows = m.pairRows()
+	}
+
+	if m.loadErr != nil {
+		rows = append(rows, tui.RenderError(m.loadErr))
+	} else if m.sharedState.Result != nil {
+		rows = append(rows, tui.RenderResult(m.sharedState.Result))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		MarginLeft(3).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m *DuplicatePageModel) pairRows() []string {
+	rows := []string{"DUPLICATES"}
+
+	if len(m.pairs) == 0 && m.loadErr == nil {
+		rows = append(rows, lipgloss.NewStyle().
+			MarginTop(1).
+			Foreground(tui.InactiveColor).
+			Render("  no likely duplicates"))
+	}
+
+	for i, pair := range m.pairs {
+		line := fmt.Sprintf(
+			"%3.0f%%  %-25s %-25s %s",
+			pair.Score*100,
+			trimRunes(pair.A.Name, 25),
+			trimRunes(pair.B.Name, 25),
+			strings.Join(pair.Reasons, ", "),
+		)
+
+		style := lipgloss.NewStyle().MarginTop(1)
+		if i == m.pairIndex {
+			rows = append(rows, style.Render("> "+line))
+		} else {
+			rows = append(rows, style.Foreground(tui.InactiveColor).Render("  "+line))
+		}
+	}
+
+	return append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("enter merge • esc back"))
+}
+
+// mergeRows show each field of both records, the picked one highlighted.
+func (m *DuplicatePageModel) mergeRows() []string {
+	pair := m.pairs[m.pairIndex]
+	sides := [2]model.FormData{pair.A, pair.B}
+
+	rows := []string{"MERGE"}
+
+	row := func(index int, name string, values [2]string) string {
+		cells := make([]string, 2)
+		for side, value := range values {
+			style := lipgloss.NewStyle().Width(mergeValueWidth + 3).Foreground(tui.InactiveColor)
+			mark := "  "
+			if m.picks[index] == side {
+				style = style.Foreground(tui.InfoColor)
+				mark = "● "
+			}
+			cells[side] = style.Render(mark + trimRunes(value, mergeValueWidth))
+		}
+
+		cursor := "  "
+		if index == m.rowIndex {
+			cursor = "> "
+		}
+		return lipgloss.NewStyle().MarginTop(1).Render(
+			fmt.Sprintf("%s%-18s ", cursor, name) + cells[leftSide] + cells[rightSide],
+		)
+	}
+
+	for i, field := range duplicate.Fields {
+		rows = append(rows, row(i, field.Name, [2]string{field.Value(sides[0]), field.Value(sides[1])}))
+	}
+	rows = append(rows, row(len(duplicate.Fields), "Keep record", [2]string{recordLabel(sides[0]), recordLabel(sides[1])}))
+
+	return append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("←/→ pick • enter merge • esc back"))
+}
+
+// recordLabel tells the records of a pair apart by when they were saved.
+func recordLabel(fd model.FormData) string {
+	if fd.UpdatedAt.IsZero() {
+		return fd.ID
+	}
+	return "saved " + fd.UpdatedAt.Format(config.App.DateFormat+" 15:04")
+}
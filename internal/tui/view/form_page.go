@@ -1,24 +1,35 @@
 package view
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/draft"
+	"github.com/bgics/pmjay-go/hbp"
+	"github.com/bgics/pmjay-go/icd"
 	"github.com/bgics/pmjay-go/internal/tui"
 	"github.com/bgics/pmjay-go/model"
-	"github.com/bgics/pmjay-go/pdf"
+	"github.com/bgics/pmjay-go/validate"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	datepicker "github.com/ethanefung/bubble-datepicker"
 )
 
 const (
 	nameIndex = iota
+	// addressIndex is the first of the address parts, see addressFields
 	addressIndex
-	diagnosisIndex
+	diagnosisIndex = iota + addressParts - 1
+	packagesIndex
 	dateIndex
 	doaIndex
 	dobIndex
@@ -28,76 +39,245 @@ const (
 	saveBtnIndex
 )
 
+// the zones of the form that can be clicked besides the fields, see
+// fieldZone
+const (
+	suggestionZone = "form.suggestion"
+	packageZone    = "form.package"
+	addressZone    = "form.address"
+	datePickerZone = "form.datepicker"
+)
+
+const (
+	// minSuggestionChars is how much of a diagnosis is typed before
+	// suggestions are offered
+	minSuggestionChars = 2
+	maxSuggestions     = 6
+)
+
 type FormPageModel struct {
+	recordID string
+
 	nameInput      textinput.Model
-	addressInput   textinput.Model
+	address        addressFields
 	diagnosisInput textinput.Model
 
+	// diagnosisCode is the ICD-10 code picked for diagnosisCodeText. It is
+	// dropped once the diagnosis is edited to something else.
+	diagnosisCode     string
+	diagnosisCodeText string
+
+	// suggestions are offered for the diagnosis being typed, taken from the
+	// catalogue and pastDiagnoses
+	pastDiagnoses  []icd.Use
+	suggestions    []icd.Suggestion
+	suggestionList dropdown
+
+	// packages are the HBP packages claimed, packageInput searches the
+	// master for more
+	packages     []model.Package
+	packageInput textinput.Model
+	packageHits  []hbp.Package
+	packageList  dropdown
+
 	gender model.Gender
 
+	// siblingGroup and birthOrder link the record to the others of a
+	// multiple birth, see model.FormData
+	siblingGroup string
+	birthOrder   int
+
 	date            time.Time
 	dateOfAdmission time.Time
 	dateOfBirth     time.Time
 
+	dateInput textinput.Model
+	doaInput  textinput.Model
+	dobInput  textinput.Model
+	// dateTyped is set once a key is typed into the focused date field, the
+	// first character typed replaces the date shown
+	dateTyped bool
+	// dateErr is why the text in the focused date field is not a date
+	dateErr error
+
+	// submitted is set once the form is printed or saved, empty required
+	// fields are only marked after that
+	submitted bool
+
 	numDays    int
 	fieldIndex int
 
 	datePicker     datepicker.Model
 	datePickerMode bool
 
+	// saved is the form as it was opened, to tell if it has unsaved edits
+	saved  model.FormData
+	dialog *dialogModel
+
+	// drafted is the form as the draft was last written and draftedAt when,
+	// draftSeq tells the latest pending draft save apart from earlier ones
+	drafted   model.FormData
+	draftedAt time.Time
+	draftSeq  int
+
 	sharedState *tui.SharedState
 }
 
+type draftSaveMsg struct {
+	form *FormPageModel
+	seq  int
+}
+
 func NewFormPageModel(sharedState *tui.SharedState) *FormPageModel {
 	m := &FormPageModel{}
 
 	m.nameInput = makeTextInput(true, config.NAME)
-	m.addressInput = makeTextInput(false, config.ADDRESS1, config.ADDRESS2, config.ADDRESS3)
+	m.address = newAddressFields()
 	m.diagnosisInput = makeTextInput(false, config.DIAGNOSIS)
+	m.packageInput = makeTextInput(false)
+	m.packageInput.Placeholder = "type a package code or name"
+
+	m.dateInput = makeDateInput()
+	m.doaInput = makeDateInput()
+	m.dobInput = makeDateInput()
 
 	m.numDays = 1
 
-	m.datePicker = makeDateInput()
+	m.datePicker = makeDatePicker()
 	m.datePickerMode = false
 
 	m.sharedState = sharedState
 
+	pastDiagnoses, err := m.sharedState.Service.PastDiagnoses(m.sharedState.User)
+	if err != nil {
+		slog.Warn("cannot load past diagnoses", "err", err)
+	}
+	m.pastDiagnoses = pastDiagnoses
+
+	if d := m.sharedState.Draft; d != nil {
+		m.setFormWithRecord(d.Record)
+		m.numDays = max(d.NumDays, 1)
+		m.saved = d.Original
+		m.drafted = d.Record
+		m.draftedAt = d.SavedAt
+		m.sharedState.Draft = nil
+		m.resetDateInputs()
+
+		return m
+	}
+
 	if m.sharedState.LastPageIndex == tui.SEARCH_PAGE {
 		record := m.sharedState.SelectedRecord
 		m.setFormWithRecord(record)
 	} else {
-		m.date = time.Now()
-		m.dateOfAdmission = time.Now()
-		m.dateOfBirth = time.Now()
+		m.date = today()
+		m.dateOfAdmission = m.date
+		m.dateOfBirth = m.date
 		m.gender = model.Male
 	}
+	m.resetDateInputs()
+
+	m.saved = m.formData()
+	// a new sibling is not saved yet, unlike the records opened from search
+	if m.recordID == "" && m.siblingGroup != "" {
+		m.saved = model.FormData{}
+	}
+	m.drafted = m.saved
 
 	return m
 }
 
 func (m *FormPageModel) setFormWithRecord(record model.FormData) {
+	m.recordID = record.ID
 	m.nameInput.SetValue(record.Name)
-	m.addressInput.SetValue(record.Address)
+	m.address.set(record.Address, record.AddressParts)
 	m.diagnosisInput.SetValue(record.Diagnosis)
+	m.diagnosisCode = record.DiagnosisCode
+	m.diagnosisCodeText = record.Diagnosis
+	m.packages = slices.Clone(record.Packages)
 
 	m.date = record.Date
 	m.dateOfAdmission = record.DateOfAdmission
 	m.dateOfBirth = record.DateOfBirth
 
 	m.gender = record.Gender
+
+	m.siblingGroup = record.SiblingGroup
+	m.birthOrder = record.BirthOrder
 }
 
 func (m *FormPageModel) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// Update handles msg and schedules a draft save if it changed the form.
 func (m *FormPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(draftSaveMsg); ok {
+		if msg.form == m && msg.seq == m.draftSeq {
+			m.saveDraft()
+		}
+		return m, nil
+	}
+
+	updated, cmd := m.update(msg)
+	if updated != m || formDataEqual(m.formData(), m.drafted) {
+		return updated, cmd
+	}
+
+	m.draftSeq++
+	seq := m.draftSeq
+	return m, tea.Batch(cmd, tea.Tick(config.DraftSaveDelay, func(time.Time) tea.Msg {
+		return draftSaveMsg{form: m, seq: seq}
+	}))
+}
+
+func (m *FormPageModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.suggestionList.isOpen() {
+		if picked, handled := m.suggestionList.handleKey(msg.String()); handled {
+			if picked >= 0 {
+				m.pickSuggestion(m.suggestions[picked])
+			}
+			return m, nil
+		}
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.fieldIndex == packagesIndex {
+		if picked, handled := m.packageList.handleKey(msg.String()); handled {
+			if picked >= 0 {
+				m.addPackage(m.packageHits[picked])
+			}
+			return m, nil
+		}
+
+		if msg.String() == "backspace" && m.packageInput.Value() == "" && len(m.packages) > 0 {
+			m.packages = m.packages[:len(m.packages)-1]
+			return m, nil
+		}
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if part, isAddress := isAddressField(m.fieldIndex); isAddress && m.address.handleKey(part, msg.String()) {
+			return m, nil
+		}
+	}
+
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			m.sharedState.LastPageIndex = tui.FORM_PAGE
-			return m, tui.ChangePageCmd(tui.START_PAGE)
+			return m.confirmLeave()
+		case "ctrl+b":
+			return m.addSibling()
 		case "tab", "down", "shift+tab", "up":
 			if m.datePickerMode {
 				return m.handleDatePicker(msg)
@@ -114,56 +294,43 @@ func (m *FormPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if m.fieldIndex == genderIndex {
-				return m.handleGenderInput()
+				return m.handleGenderInput(msg.String())
+			}
+
+			if m.isDateField() {
+				return m.handleDateInput(msg)
 			}
 
 			return m, nil
 		case "enter":
-			if dateIndex <= m.fieldIndex && m.fieldIndex <= dobIndex {
-				if m.datePickerMode {
-					m.datePickerMode = false
-					m.blurDatePicker()
-				} else {
-					m.datePickerMode = true
-					m.focusDatePicker()
-				}
-
+			if m.isDateField() {
+				m.toggleDatePicker()
 				return m, nil
-			} else if m.fieldIndex == printBtnIndex {
-				fd, err := m.validateInput()
-				if err != nil {
-					return m, tui.ErrorCmd(err)
-				}
-
-				printCmd := m.generatePrintCmd(fd)
-				saveCmd := m.generateSaveCmd(fd)
-
-				m.sharedState.LastPageIndex = tui.FORM_PAGE
-				return m, tea.Batch(printCmd, saveCmd, tui.ChangePageCmd(tui.START_PAGE))
-			} else if m.fieldIndex == saveBtnIndex {
-				fd, err := m.validateInput()
-				if err != nil {
-					return m, tui.ErrorCmd(err)
-				}
-
-				saveCmd := m.generateSaveCmd(fd)
-
-				m.sharedState.LastPageIndex = tui.FORM_PAGE
-				return m, tea.Batch(saveCmd, tui.ChangePageCmd(tui.START_PAGE))
+			} else if m.fieldIndex == printBtnIndex || m.fieldIndex == saveBtnIndex {
+				return m.pressButton()
 			}
 		}
+
+		if m.fieldIndex == genderIndex && msg.Type == tea.KeyRunes {
+			return m.handleGenderInput(msg.String())
+		}
 	}
 
 	return m.handleFormInput(msg)
 }
 
 func (m *FormPageModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
 	inputFields := m.renderTextInputs()
 
 	dateFields := m.renderDateInputs()
 	genderField := m.renderGenderField()
 	numDaysField := m.renderNumDaysField()
 	formButtons := m.renderButtons()
+	dirtyMark := m.renderDirtyMark()
 
 	errorMsg := m.renderError()
 
@@ -173,6 +340,7 @@ func (m *FormPageModel) View() string {
 		genderField,
 		numDaysField,
 		formButtons,
+		dirtyMark,
 	)
 
 	datePicker := m.renderDatePicker()
@@ -195,83 +363,402 @@ func (m *FormPageModel) View() string {
 		)
 }
 
-func (m *FormPageModel) generatePrintCmd(fd model.FormData) tea.Cmd {
-	return func() tea.Msg {
-		err := pdf.GeneratePDF(config.OutputFileName, fd, m.numDays)
-		if err != nil {
-			return tui.ErrorMsg{Err: err}
-		}
+// addSibling opens a new form for the next born of the multiple birth of the
+// saved record, with the fields they share filled in.
+func (m *FormPageModel) addSibling() (tea.Model, tea.Cmd) {
+	if m.recordID == "" || m.isDirty() {
+		return m, tui.ErrorCmd(fmt.Errorf("save the record before adding a sibling"))
+	}
 
-		err = pdf.PrintPDF(config.OutputFileName)
-		if err != nil {
-			return tui.ErrorMsg{Err: err}
-		}
+	sibling, err := m.sharedState.Service.NewSibling(m.sharedState.User, m.formData())
+	if err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	m.discardDraft()
+	m.sharedState.SelectedRecord = sibling
+	m.sharedState.LastPageIndex = tui.SEARCH_PAGE
+	return m, tui.ChangePageCmd(tui.FORM_PAGE)
+}
 
-		return nil
+func (m *FormPageModel) siblingHint() string {
+	if m.recordID == "" {
+		return ""
 	}
+	return "ctrl+b add a sibling"
 }
 
-func (m *FormPageModel) generateSaveCmd(fd model.FormData) tea.Cmd {
-	return func() tea.Msg {
-		if err := m.sharedState.Store.AddRecord(fd); err != nil {
+// confirmLeave goes back to the start page, asking first whether to save or
+// discard unsaved edits.
+func (m *FormPageModel) confirmLeave() (tea.Model, tea.Cmd) {
+	discardAndLeave := func() tea.Cmd {
+		m.discardDraft()
+		m.sharedState.LastPageIndex = tui.FORM_PAGE
+		return tui.ChangePageCmd(tui.START_PAGE)
+	}
+
+	if !m.isDirty() {
+		return m, discardAndLeave()
+	}
+
+	saveAndLeave := func() tea.Cmd {
+		fd, err := m.validateInput()
+		if err != nil {
 			return tui.ErrorCmd(err)
 		}
-		return nil
+
+		return m.leaveWith(func() tea.Cmd { return m.generateSaveCmd(fd) })
+	}
+
+	name := m.nameInput.Value()
+	if name == "" {
+		name = "this patient"
+	}
+	m.dialog = newChoiceDialog(
+		"Unsaved changes",
+		fmt.Sprintf("The changes to %s are not saved yet.", name),
+		dialogOption{label: "SAVE", key: "s", action: saveAndLeave},
+		dialogOption{label: "DISCARD", key: "d", action: discardAndLeave},
+	)
+
+	return m, nil
+}
+
+// saveDraft writes the form as a draft while it has unsaved edits, and
+// removes the draft once it has none.
+func (m *FormPageModel) saveDraft() {
+	fd := m.formData()
+	if !m.isDirty() {
+		m.discardDraft()
+		m.drafted = fd
+		m.draftedAt = time.Time{}
+		return
 	}
+
+	d := draft.Draft{Record: fd, Original: m.saved, NumDays: m.numDays, SavedAt: time.Now()}
+	if err := m.sharedState.Service.SaveDraft(m.sharedState.User, d); err != nil {
+		slog.Warn("cannot save draft", "err", err)
+		return
+	}
+	m.drafted = fd
+	m.draftedAt = d.SavedAt
 }
 
-func (m *FormPageModel) validateInput() (model.FormData, error) {
-	if m.nameInput.Value() == "" {
-		return model.FormData{}, fmt.Errorf("name field is empty")
+// FlushDraft writes the draft now if an edit is still waiting for the
+// delayed save, before the app quits or locks.
+func (m *FormPageModel) FlushDraft() {
+	if !formDataEqual(m.formData(), m.drafted) {
+		m.saveDraft()
+	}
+}
+
+func (m *FormPageModel) discardDraft() {
+	if err := m.sharedState.Service.DiscardDraft(); err != nil {
+		slog.Warn("cannot discard draft", "err", err)
 	}
-	if m.addressInput.Value() == "" {
-		return model.FormData{}, fmt.Errorf("address field is empty")
+}
+
+// discardSavedDraft returns a func for a job to remove the draft of the form
+// as it is now once the job succeeds. By then another form may have written
+// its own draft, which is kept.
+func (m *FormPageModel) discardSavedDraft() func() {
+	service, savedAt := m.sharedState.Service, m.draftedAt
+	return func() {
+		if savedAt.IsZero() {
+			return
+		}
+		if err := service.DiscardSavedDraft(savedAt); err != nil {
+			slog.Warn("cannot discard draft", "err", err)
+		}
 	}
-	if m.diagnosisInput.Value() == "" {
-		return model.FormData{}, fmt.Errorf("diagnosis field is empty")
+}
+
+// confirmPrint prints fd and goes back to the start page, asking first if it
+// is more than a few pages.
+func (m *FormPageModel) confirmPrint(fd model.FormData) (tea.Model, tea.Cmd) {
+	printAndLeave := func() tea.Cmd {
+		return m.leaveWith(func() tea.Cmd { return m.generatePrintCmd(fd) })
 	}
 
-	if m.date.Compare(m.dateOfAdmission) < 0 {
-		return model.FormData{}, fmt.Errorf("doa is after date")
+	if m.numDays <= config.LargePrintPages {
+		return m, printAndLeave()
 	}
-	if m.date.Compare(m.dateOfBirth) < 0 {
-		return model.FormData{}, fmt.Errorf("dob is after date")
+
+	lastDate := fd.Date.AddDate(0, 0, m.numDays-1)
+	m.dialog = newConfirmDialog(
+		"Print",
+		fmt.Sprintf("Print %d pages for %s, one for each day from %s to %s?",
+			m.numDays, fd.Name, fd.Date.Format(config.App.DateFormat), lastDate.Format(config.App.DateFormat)),
+		"PRINT",
+		printAndLeave,
+	)
+
+	return m, nil
+}
+
+func (m *FormPageModel) generatePrintCmd(fd model.FormData) tea.Cmd {
+	numDays := m.numDays
+	user := m.sharedState.User
+	done := "Printed 1 page"
+	if numDays > 1 {
+		done = fmt.Sprintf("Printed %d pages", numDays)
 	}
-	if m.dateOfAdmission.Compare(m.dateOfBirth) < 0 {
-		return model.FormData{}, fmt.Errorf("dob is after doa")
+
+	discardDraft := m.discardSavedDraft()
+
+	return m.sharedState.Jobs.Start("Printing", done, fd.Name, func() error {
+		_, err := m.sharedState.Service.PrintRecord(context.Background(), user, fd, numDays)
+		if err == nil {
+			discardDraft()
+		}
+		return err
+	})
+}
+
+func (m *FormPageModel) generateSaveCmd(fd model.FormData) tea.Cmd {
+	user := m.sharedState.User
+	discardDraft := m.discardSavedDraft()
+
+	return m.sharedState.Jobs.Start("Saving", "Saved", fd.Name, func() error {
+		_, err := m.sharedState.Service.SaveRecord(user, fd)
+		if err == nil {
+			discardDraft()
+		}
+		return err
+	})
+}
+
+func (m *FormPageModel) validateInput() (model.FormData, error) {
+	m.submitted = true
+
+	fd := m.formData()
+	if err := validate.Record(fd).Err(); err != nil {
+		return model.FormData{}, err
 	}
 
+	return fd, nil
+}
+
+// fieldProblem is what is wrong with field, if anything. An empty required
+// field is not a problem until the form is submitted.
+func (m *FormPageModel) fieldProblem(field validate.Field) (validate.Problem, bool) {
+	p, ok := validate.Record(m.formData()).For(field)
+	if !ok || (p.Kind == validate.KindMissing && !m.submitted) {
+		return validate.Problem{}, false
+	}
+	return p, true
+}
+
+func (m *FormPageModel) formData() model.FormData {
 	return model.FormData{
+		ID:              m.recordID,
 		Name:            m.nameInput.Value(),
-		Address:         m.addressInput.Value(),
+		Address:         m.address.value().String(),
+		AddressParts:    m.address.value(),
 		Diagnosis:       m.diagnosisInput.Value(),
+		DiagnosisCode:   m.currentDiagnosisCode(),
+		Packages:        slices.Clone(m.packages),
 		Gender:          m.gender,
+		SiblingGroup:    m.siblingGroup,
+		BirthOrder:      m.birthOrder,
 		Date:            m.date,
 		DateOfAdmission: m.dateOfAdmission,
 		DateOfBirth:     m.dateOfBirth,
-	}, nil
+	}
+}
+
+// isDirty reports whether the form has changed since it was opened.
+func (m *FormPageModel) isDirty() bool {
+	return !formDataEqual(m.formData(), m.saved)
+}
+
+// formDataEqual compares the fields shown on the form.
+func formDataEqual(a, b model.FormData) bool {
+	return a.Name == b.Name &&
+		a.Address == b.Address &&
+		a.AddressParts == b.AddressParts &&
+		a.Diagnosis == b.Diagnosis &&
+		a.DiagnosisCode == b.DiagnosisCode &&
+		slices.Equal(a.Packages, b.Packages) &&
+		a.Gender == b.Gender &&
+		a.SiblingGroup == b.SiblingGroup &&
+		a.BirthOrder == b.BirthOrder &&
+		a.Date.Equal(b.Date) &&
+		a.DateOfAdmission.Equal(b.DateOfAdmission) &&
+		a.DateOfBirth.Equal(b.DateOfBirth)
+}
+
+// handleGenderInput moves through the genders with left and right, or picks
+// the one whose code is typed.
+func (m *FormPageModel) handleGenderInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "left", "right":
+		delta := 1
+		if key == "left" {
+			delta = -1
+		}
+		i := slices.Index(model.Genders, m.gender)
+		m.gender = model.Genders[cyclicAdjust(i+delta, 0, len(model.Genders)-1)]
+	default:
+		if gender, err := model.ParseGender(key); err == nil && len(key) == 1 {
+			m.gender = gender
+		}
+	}
+
+	return m, nil
 }
 
-func (m *FormPageModel) handleGenderInput() (tea.Model, tea.Cmd) {
-	if m.gender == model.Male {
-		m.gender = model.Female
+func (m *FormPageModel) toggleDatePicker() {
+	if m.datePickerMode {
+		m.datePickerMode = false
+		m.blurDatePicker()
 	} else {
-		m.gender = model.Male
+		m.datePickerMode = true
+		m.focusDatePicker()
+	}
+}
+
+// pressButton prints or saves the form, for the focused button.
+func (m *FormPageModel) pressButton() (tea.Model, tea.Cmd) {
+	fd, err := m.validateInput()
+	if err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	if m.fieldIndex == printBtnIndex {
+		return m.confirmPrint(fd)
+	}
+
+	return m, m.leaveWith(func() tea.Cmd { return m.generateSaveCmd(fd) })
+}
+
+// leaveWith goes back to the start page and runs the job newJob makes there.
+// The form is written as a draft first, which the job removes once it
+// succeeds, so the edits are not lost if it fails.
+func (m *FormPageModel) leaveWith(newJob func() tea.Cmd) tea.Cmd {
+	m.saveDraft()
+	m.sharedState.LastPageIndex = tui.FORM_PAGE
+	return tea.Sequence(tui.ChangePageCmd(tui.START_PAGE), newJob())
+}
+
+// handleMouse picks from an open list or the date picker, or focuses the
+// field clicked. A click on the gender or DAYS field also changes it, and
+// on a button presses it.
+func (m *FormPageModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	zones := m.sharedState.Zones
+
+	if picked := m.suggestionList.click(zones, suggestionZone, msg); picked >= 0 {
+		m.pickSuggestion(m.suggestions[picked])
+		return m, nil
+	}
+	if picked := m.packageList.click(zones, packageZone, msg); picked >= 0 {
+		m.addPackage(m.packageHits[picked])
+		return m, nil
+	}
+	if part, isAddress := isAddressField(m.fieldIndex); isAddress {
+		if picked := m.address.list.click(zones, addressZone, msg); picked >= 0 {
+			m.address.pick(part, m.address.hits[picked])
+			return m, nil
+		}
+	}
+
+	if m.datePickerMode {
+		if _, _, inPicker := zones.Pos(datePickerZone, msg); inPicker {
+			return m.handleDatePickerMouse(msg)
+		}
+	}
+
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+
+	for index := nameIndex; index <= saveBtnIndex; index++ {
+		if _, _, ok := zones.Pos(fieldZone(index), msg); !ok {
+			continue
+		}
+
+		if m.datePickerMode {
+			m.toggleDatePicker()
+		}
+
+		var cmd tea.Cmd
+		if m.fieldIndex != index {
+			m.fieldIndex = index
+			cmd = m.updateFocus()
+		}
+
+		switch {
+		case index == genderIndex && msg.Button == tea.MouseButtonLeft:
+			m.handleGenderInput("right")
+		case index == numDaysIndex && msg.Button == tea.MouseButtonLeft:
+			m.handleNumDaysInput("right")
+		case index == numDaysIndex && msg.Button == tea.MouseButtonRight:
+			m.handleNumDaysInput("left")
+		case m.isDateField() && msg.Button == tea.MouseButtonLeft:
+			m.toggleDatePicker()
+		case (index == printBtnIndex || index == saveBtnIndex) && msg.Button == tea.MouseButtonLeft:
+			return m.pressButton()
+		}
+
+		return m, cmd
 	}
 
 	return m, nil
 }
 
-func (m *FormPageModel) focusDatePicker() {
-	switch m.fieldIndex {
-	case dateIndex:
-		m.datePicker.SetTime(m.date)
-	case doaIndex:
-		m.datePicker.SetTime(m.dateOfAdmission)
-	case dobIndex:
-		m.datePicker.SetTime(m.dateOfBirth)
+// dayNumber is a day of the month as the date picker shows it.
+var dayNumber = regexp.MustCompile(`\b\d{2}\b`)
+
+// handleDatePickerMouse sets the date to the day clicked, keeping the time
+// of day, and closes the picker. The wheel moves through the months.
+func (m *FormPageModel) handleDatePickerMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		if msg.Button == tea.MouseButtonWheelUp {
+			m.datePicker.LastMonth()
+		} else {
+			m.datePicker.NextMonth()
+		}
+		*m.dateAt(m.fieldIndex) = m.datePicker.Time
+		m.resetDateInputs()
+		return m, nil
+	}
+
+	if !m.sharedState.Zones.Hit(datePickerZone, msg) {
+		return m, nil
+	}
+	x, y, _ := m.sharedState.Zones.Pos(datePickerZone, msg)
+
+	lines := strings.Split(ansi.Strip(m.datePicker.View()), "\n")
+	if y >= len(lines) {
+		return m, nil
+	}
+
+	// a day takes its two digits and the padding either side of them
+	for _, loc := range dayNumber.FindAllStringIndex(lines[y], -1) {
+		if x < loc[0]-1 || x > loc[1] {
+			continue
+		}
+
+		day, _ := strconv.Atoi(lines[y][loc[0]:loc[1]])
+		prev := *m.dateAt(m.fieldIndex)
+		picked := m.datePicker.Time
+		*m.dateAt(m.fieldIndex) = time.Date(picked.Year(), picked.Month(), day,
+			prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(), prev.Location())
+
+		m.resetDateInputs()
+		m.toggleDatePicker()
+		break
 	}
 
+	return m, nil
+}
+
+func (m *FormPageModel) focusDatePicker() {
+	m.resetDateInputs()
+	m.dateInputAt(m.fieldIndex).Blur()
+
+	m.datePicker.SetTime(*m.dateAt(m.fieldIndex))
 	m.datePicker.SelectDate()
 	m.datePicker.SetFocus(datepicker.FocusCalendar)
 }
@@ -279,6 +766,9 @@ func (m *FormPageModel) focusDatePicker() {
 func (m *FormPageModel) blurDatePicker() {
 	m.datePicker.UnselectDate()
 	m.datePicker.SetFocus(datepicker.FocusNone)
+
+	m.resetDateInputs()
+	m.dateInputAt(m.fieldIndex).Focus()
 }
 
 func (m *FormPageModel) handleDatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -307,29 +797,185 @@ func (m *FormPageModel) handleDatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if m.datePicker.Time != prev {
-		switch m.fieldIndex {
-		case dateIndex:
-			m.date = m.datePicker.Time
-		case doaIndex:
-			m.dateOfAdmission = m.datePicker.Time
-		case dobIndex:
-			m.dateOfBirth = m.datePicker.Time
-		}
+		*m.dateAt(m.fieldIndex) = m.datePicker.Time
+		m.resetDateInputs()
 	}
 
 	return m, cmd
 }
 
 func (m *FormPageModel) handleFormInput(msg tea.Msg) (tea.Model, tea.Cmd) {
-	cmd := make([]tea.Cmd, 3)
+	if msg, ok := msg.(tea.KeyMsg); ok && m.isDateField() && !m.datePickerMode {
+		return m.handleDateInput(msg)
+	}
+
+	cmd := make([]tea.Cmd, 7)
+	diagnosis := m.diagnosisInput.Value()
+	packageQuery := m.packageInput.Value()
 
 	m.nameInput, cmd[0] = m.nameInput.Update(msg)
-	m.addressInput, cmd[1] = m.addressInput.Update(msg)
+	addressPart, isAddress := isAddressField(m.fieldIndex)
+	if !isAddress {
+		addressPart = -1
+	}
+	var err error
+	cmd[1], err = m.address.update(msg, addressPart, m.sharedState.Service)
+	if err != nil {
+		cmd = append(cmd, tui.ErrorCmd(err))
+	}
 	m.diagnosisInput, cmd[2] = m.diagnosisInput.Update(msg)
+	m.dateInput, cmd[3] = m.dateInput.Update(msg)
+	m.doaInput, cmd[4] = m.doaInput.Update(msg)
+	m.dobInput, cmd[5] = m.dobInput.Update(msg)
+	m.packageInput, cmd[6] = m.packageInput.Update(msg)
+
+	if m.diagnosisInput.Value() != diagnosis {
+		m.updateSuggestions()
+	}
+	if m.packageInput.Value() != packageQuery {
+		if err := m.updatePackageHits(); err != nil {
+			cmd = append(cmd, tui.ErrorCmd(fmt.Errorf("cannot search the package master: %w", err)))
+		}
+	}
 
 	return m, tea.Batch(cmd...)
 }
 
+// updateSuggestions offers the diagnoses that match what is typed.
+func (m *FormPageModel) updateSuggestions() {
+	m.suggestions = nil
+	m.suggestionList.close()
+
+	value := m.diagnosisInput.Value()
+	if len([]rune(strings.TrimSpace(value))) < minSuggestionChars || value == m.diagnosisCodeText {
+		return
+	}
+
+	m.suggestions = m.sharedState.Service.Catalogue().Suggest(value, m.pastDiagnoses, maxSuggestions)
+
+	lines := make([]string, len(m.suggestions))
+	for i, suggestion := range m.suggestions {
+		lines[i] = fmt.Sprintf("%-7s %s", suggestion.Code, suggestion.Text)
+		if suggestion.Count > 0 {
+			lines[i] += fmt.Sprintf("  (used %d×)", suggestion.Count)
+		}
+	}
+	m.suggestionList = newDropdown(lines)
+}
+
+func (m *FormPageModel) pickSuggestion(suggestion icd.Suggestion) {
+	m.diagnosisInput.SetValue(suggestion.Text)
+	m.diagnosisInput.CursorEnd()
+	m.diagnosisCode = suggestion.Code
+	m.diagnosisCodeText = suggestion.Text
+}
+
+// updatePackageHits lists the packages of the master that match what is
+// typed in the packages field.
+func (m *FormPageModel) updatePackageHits() error {
+	m.packageHits = nil
+	m.packageList.close()
+
+	hits, err := m.sharedState.Service.SearchPackages(m.packageInput.Value(), maxSuggestions)
+	if err != nil {
+		return err
+	}
+
+	m.packageHits = hits
+	lines := make([]string, len(hits))
+	for i, p := range hits {
+		lines[i] = fmt.Sprintf("%-10s %s  Rs %d", p.Code, p.Name, p.Rate)
+		if p.PreAuth {
+			lines[i] += "  pre-auth"
+		}
+	}
+	m.packageList = newDropdown(lines)
+
+	return nil
+}
+
+// addPackage claims p for the admission at its current rate, unless it
+// already is.
+func (m *FormPageModel) addPackage(p hbp.Package) {
+	m.packageInput.SetValue("")
+	m.packageHits = nil
+
+	if slices.ContainsFunc(m.packages, func(claimed model.Package) bool { return claimed.Code == p.Code }) {
+		return
+	}
+	m.packages = append(m.packages, model.Package{Code: p.Code, Rate: p.Rate})
+}
+
+// currentDiagnosisCode is the code picked for the diagnosis, empty if none
+// was or the diagnosis was edited since.
+func (m *FormPageModel) currentDiagnosisCode() string {
+	if m.diagnosisInput.Value() != m.diagnosisCodeText {
+		return ""
+	}
+	return m.diagnosisCode
+}
+
+// handleDateInput types msg into the focused date field and sets the date
+// as soon as the text is one.
+func (m *FormPageModel) handleDateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	input := m.dateInputAt(m.fieldIndex)
+	if !m.dateTyped && msg.Type == tea.KeyRunes {
+		input.SetValue("")
+	}
+	m.dateTyped = true
+
+	var cmd tea.Cmd
+	*input, cmd = input.Update(msg)
+
+	date, err := parseDateInput(input.Value(), m.date, m.fieldIndex == dobIndex)
+	m.dateErr = err
+	if err == nil {
+		*m.dateAt(m.fieldIndex) = date
+	}
+
+	return m, cmd
+}
+
+func (m *FormPageModel) isDateField() bool {
+	return dateIndex <= m.fieldIndex && m.fieldIndex <= dobIndex
+}
+
+// dateAt is the date of the date field at index.
+func (m *FormPageModel) dateAt(index int) *time.Time {
+	switch index {
+	case doaIndex:
+		return &m.dateOfAdmission
+	case dobIndex:
+		return &m.dateOfBirth
+	default:
+		return &m.date
+	}
+}
+
+func (m *FormPageModel) dateInputAt(index int) *textinput.Model {
+	switch index {
+	case doaIndex:
+		return &m.doaInput
+	case dobIndex:
+		return &m.dobInput
+	default:
+		return &m.dateInput
+	}
+}
+
+// resetDateInputs shows the dates in the date fields again, dropping any
+// text typed that is not a date.
+func (m *FormPageModel) resetDateInputs() {
+	for _, index := range []int{dateIndex, doaIndex, dobIndex} {
+		input := m.dateInputAt(index)
+		input.SetValue(m.dateAt(index).Format(config.App.DateFormat))
+		input.CursorEnd()
+	}
+
+	m.dateTyped = false
+	m.dateErr = nil
+}
+
 func (m *FormPageModel) handleNumDaysInput(key string) (tea.Model, tea.Cmd) {
 	switch key {
 	case "right":
@@ -352,27 +998,34 @@ func (m *FormPageModel) handleFormNav(key string) (tea.Model, tea.Cmd) {
 		m.fieldIndex = cyclicAdjust(m.fieldIndex-1, nameIndex, saveBtnIndex)
 	}
 
-	if m.fieldIndex <= dateIndex || m.fieldIndex == saveBtnIndex {
-		cmd := m.updateFocus()
-		return m, cmd
-	}
-	return m, nil
+	return m, m.updateFocus()
 }
 
 func (m *FormPageModel) updateFocus() tea.Cmd {
 	m.nameInput.Blur()
-	m.addressInput.Blur()
+	m.address.blur()
 	m.diagnosisInput.Blur()
+	m.dateInput.Blur()
+	m.doaInput.Blur()
+	m.dobInput.Blur()
+	m.packageInput.Blur()
+	m.resetDateInputs()
+	m.suggestionList.close()
+	m.packageList.close()
 
 	var cmd tea.Cmd
 
 	switch m.fieldIndex {
 	case nameIndex:
 		cmd = m.nameInput.Focus()
-	case addressIndex:
-		cmd = m.addressInput.Focus()
+	case addressIndex, addressIndex + blockPart, addressIndex + districtPart, addressIndex + statePart, addressIndex + pinPart:
+		cmd = m.address.focus(m.fieldIndex - addressIndex)
 	case diagnosisIndex:
 		cmd = m.diagnosisInput.Focus()
+	case packagesIndex:
+		cmd = m.packageInput.Focus()
+	case dateIndex, doaIndex, dobIndex:
+		cmd = m.dateInputAt(m.fieldIndex).Focus()
 	}
 
 	return cmd
@@ -390,35 +1043,128 @@ func cyclicAdjust(val, min, max int) int {
 
 func (m *FormPageModel) renderGenderField() string {
 	if m.fieldIndex == genderIndex {
-		return lipgloss.JoinHorizontal(
+		return m.markField(genderIndex, lipgloss.JoinHorizontal(
 			lipgloss.Center,
 			tui.FieldNameActiveStyle.Render("> GENDER"),
-			tui.SimpleFieldActiveStyle.Render(string(m.gender)),
-		)
+			tui.SimpleFieldActiveStyle.Render(m.genderText()),
+		))
 	}
-	return lipgloss.JoinHorizontal(
+	return m.markField(genderIndex, lipgloss.JoinHorizontal(
 		lipgloss.Center,
 		tui.FieldNameInactiveStyle.Render("  GENDER"),
-		tui.SimpleFieldInactiveStyle.Render(string(m.gender)),
-	)
+		tui.SimpleFieldInactiveStyle.Render(m.genderText()),
+	))
+}
+
+func fieldZone(index int) string {
+	return fmt.Sprintf("form.field.%d", index)
+}
+
+// markField marks view as the zone of the field at index, leaving out a
+// trailing newline so the zone ends on the last line of the field.
+func (m *FormPageModel) markField(index int, view string) string {
+	trimmed := strings.TrimSuffix(view, "\n")
+	return m.sharedState.Zones.Mark(fieldZone(index), trimmed) + view[len(trimmed):]
+}
+
+func (m *FormPageModel) genderText() string {
+	return fmt.Sprintf("%s  %s", m.gender, m.gender.Label())
 }
 
 func (m *FormPageModel) renderDatePicker() string {
 	if !m.datePickerMode {
 		return ""
 	}
-	return tui.DatePickerStyle.Render(m.datePicker.View())
+	return tui.DatePickerStyle.Render(m.sharedState.Zones.Mark(datePickerZone, m.datePicker.View()))
 }
 
 func (m *FormPageModel) renderTextInputs() string {
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		makeTextField("NAME", m.nameInput.View(), m.fieldIndex == nameIndex),
-		makeTextField("ADDRESS", m.addressInput.View(), m.fieldIndex == addressIndex),
-		makeTextField("DIAGNOSIS", m.diagnosisInput.View(), m.fieldIndex == diagnosisIndex),
+		m.renderTextField("NAME", m.nameInput.View(), m.siblingHint(), nameIndex, validate.Name),
+		m.renderAddress(),
+		m.renderTextField("DIAGNOSIS", m.diagnosisInput.View(), m.renderDiagnosisCode(), diagnosisIndex, validate.Diagnosis),
+		m.suggestionList.View(m.sharedState.Zones, suggestionZone),
+		m.renderTextField("PACKAGES", m.packageInput.View(), "", packagesIndex, validate.Packages),
+		m.packageList.View(m.sharedState.Zones, packageZone),
+		m.renderPackages(),
 	)
 }
 
+// renderPackages lists the packages claimed and their total.
+func (m *FormPageModel) renderPackages() string {
+	if len(m.packages) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, claimed := range m.packages {
+		line := fmt.Sprintf("%-10s %9s", claimed.Code, fmt.Sprintf("Rs %d", claimed.Rate))
+		if p, found, _ := m.sharedState.Service.LookupPackage(claimed.Code); found {
+			line += "  " + p.Name
+			if p.PreAuth {
+				line += lipgloss.NewStyle().Foreground(tui.WarnColor).Render("  pre-auth required")
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	total := fmt.Sprintf("%-10s %9s", "TOTAL", fmt.Sprintf("Rs %d", m.formData().PackageTotal()))
+	if m.fieldIndex == packagesIndex {
+		total += lipgloss.NewStyle().Foreground(tui.InactiveColor).Render("  backspace removes the last")
+	}
+	lines = append(lines, total)
+
+	return lipgloss.NewStyle().
+		MarginLeft(tui.FieldNameActiveStyle.GetWidth() + 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m *FormPageModel) renderDiagnosisCode() string {
+	code := m.currentDiagnosisCode()
+	if code == "" {
+		return ""
+	}
+	return "ICD-10 " + code
+}
+
+// renderTextField renders a text input with the problem of field next to it,
+// or hint if it has none.
+func (m *FormPageModel) renderTextField(fieldName, inputView, hint string, index int, fields ...validate.Field) string {
+	active := m.fieldIndex == index
+
+	fieldNameStyle := tui.FieldNameActiveStyle
+	if !active {
+		fieldNameStyle = tui.FieldNameInactiveStyle
+	}
+
+	fieldInputStyle := tui.InputActiveBorderStyle
+	if !active {
+		fieldInputStyle = tui.InputInactiveBorderStyle
+	}
+
+	note := lipgloss.NewStyle().Foreground(tui.InactiveColor).Render(hint)
+	for _, field := range fields {
+		p, ok := m.fieldProblem(field)
+		if !ok {
+			continue
+		}
+
+		color := problemColor(p)
+		fieldNameStyle = fieldNameStyle.Foreground(color)
+		fieldInputStyle = fieldInputStyle.BorderForeground(color)
+		note = lipgloss.NewStyle().Foreground(color).Render(p.Message)
+	}
+
+	return m.markField(index, lipgloss.JoinHorizontal(
+		lipgloss.Center,
+		fieldNameStyle.Render(fieldName),
+		fieldInputStyle.Render(inputView),
+		" ",
+		note,
+	))
+}
+
 func makeTextField(fieldName, inputView string, active bool) string {
 	fieldNameStyle := tui.FieldNameActiveStyle
 	if !active {
@@ -437,25 +1183,57 @@ func makeTextField(fieldName, inputView string, active bool) string {
 	)
 }
 
+func problemColor(p validate.Problem) lipgloss.Color {
+	if p.IsWarning() {
+		return tui.WarnColor
+	}
+	return tui.ErrorColor
+}
+
 func (m *FormPageModel) renderDateInputs() string {
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		m.renderDateField("DATE", m.date, dateIndex),
-		m.renderDateField("DOA", m.dateOfAdmission, doaIndex),
-		m.renderDateField("DOB", m.dateOfBirth, dobIndex),
+		m.renderDateField("DATE", m.date, dateIndex, validate.Date),
+		m.renderDateField("DOA", m.dateOfAdmission, doaIndex, validate.DateOfAdmission),
+		m.renderDateField("DOB", m.dateOfBirth, dobIndex, validate.DateOfBirth),
 	)
 }
 
-func (m *FormPageModel) renderDateField(fieldName string, date time.Time, index int) string {
-	prefix := "  "
-	if m.fieldIndex == index {
-		prefix = "> "
+func (m *FormPageModel) renderDateField(fieldName string, date time.Time, index int, field validate.Field) string {
+	var problemNote string
+	if p, ok := m.fieldProblem(field); ok {
+		problemNote = lipgloss.NewStyle().Foreground(problemColor(p)).Render(p.Message)
+	}
+
+	if m.fieldIndex != index {
+		return m.markField(index, makeDateField("  "+fieldName, date.Format(config.App.DateFormat), problemNote, false))
+	}
+
+	input := m.dateInputAt(index)
+	if m.datePickerMode {
+		return m.markField(index, makeDateField("> "+fieldName, input.Value(), problemNote, true))
 	}
 
-	return makeDateField(prefix+fieldName, date, index == m.fieldIndex)
+	var note string
+	switch {
+	case m.dateErr != nil:
+		note = lipgloss.NewStyle().Foreground(tui.ErrorColor).Render(m.dateErr.Error())
+	case input.Value() != date.Format(config.App.DateFormat):
+		note = lipgloss.NewStyle().Foreground(tui.InactiveColor).Render("= " + date.Format(config.App.DateFormat))
+	case problemNote != "":
+		note = problemNote
+	case !m.dateTyped:
+		hint := "type a date, t, y or ±days, enter for calendar"
+		if index == dobIndex {
+			hint = "type a date, t, y, ±days or an age like 5d or 3m, enter for calendar"
+		}
+		note = lipgloss.NewStyle().Foreground(tui.InactiveColor).Render(hint)
+	}
+
+	return m.markField(index, makeDateField("> "+fieldName, input.View(), note, true))
 }
 
-func makeDateField(fieldName string, date time.Time, active bool) string {
+func makeDateField(fieldName, dateView, note string, active bool) string {
 	fieldNameStyle := tui.FieldNameActiveStyle
 	if !active {
 		fieldNameStyle = tui.FieldNameInactiveStyle
@@ -469,25 +1247,28 @@ func makeDateField(fieldName string, date time.Time, active bool) string {
 	dateLine := lipgloss.JoinHorizontal(
 		lipgloss.Center,
 		fieldNameStyle.Render(fieldName),
-		dateStyle.Render(date.Format(config.DateFormat)),
+		dateStyle.Render(dateView),
 	)
+	if note != "" {
+		dateLine = lipgloss.JoinHorizontal(lipgloss.Bottom, dateLine, "  ", note)
+	}
 
 	return dateLine + "\n"
 }
 
 func (m *FormPageModel) renderNumDaysField() string {
 	if m.fieldIndex == numDaysIndex {
-		return lipgloss.JoinHorizontal(
+		return m.markField(numDaysIndex, lipgloss.JoinHorizontal(
 			lipgloss.Center,
 			tui.FieldNameActiveStyle.Render("> DAYS"),
 			tui.SimpleFieldActiveStyle.Render(strconv.Itoa(m.numDays)),
-		)
+		))
 	}
-	return lipgloss.JoinHorizontal(
+	return m.markField(numDaysIndex, lipgloss.JoinHorizontal(
 		lipgloss.Center,
 		tui.FieldNameInactiveStyle.Render("  DAYS"),
 		tui.SimpleFieldInactiveStyle.Render(strconv.Itoa(m.numDays)),
-	)
+	))
 }
 
 func (m *FormPageModel) renderButtons() string {
@@ -507,14 +1288,22 @@ func (m *FormPageModel) renderButtons() string {
 
 func (m *FormPageModel) renderButton(btnName string, index int) string {
 	if m.fieldIndex == index {
-		return tui.BtnActiveStyle.Render(btnName)
+		return m.markField(index, tui.BtnActiveStyle.Render(btnName))
 	}
-	return tui.BtnInactiveStyle.Render(btnName)
+	return m.markField(index, tui.BtnInactiveStyle.Render(btnName))
 }
 
-func (m *FormPageModel) renderError() string {
-	if err := m.sharedState.Error; err != nil {
-		return tui.ErrStyle.Render(fmt.Sprintf("[ERROR] %v", err))
+func (m *FormPageModel) renderDirtyMark() string {
+	if !m.isDirty() {
+		return ""
 	}
-	return ""
+
+	return lipgloss.NewStyle().
+		MarginLeft(8).
+		Foreground(tui.InactiveColor).
+		Render("● unsaved changes")
+}
+
+func (m *FormPageModel) renderError() string {
+	return tui.RenderResult(m.sharedState.Result)
 }
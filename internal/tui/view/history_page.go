@@ -0,0 +1,169 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/history"
+	"github.com/bgics/pmjay-go/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxHistoryRows = 10
+
+var changeFieldStyle = lipgloss.NewStyle().
+	Width(18).
+	AlignHorizontal(lipgloss.Right)
+
+type HistoryPageModel struct {
+	revisions     []history.Revision
+	revisionIndex int
+	loadErr       error
+	sharedState   *tui.SharedState
+}
+
+func NewHistoryPageModel(sharedState *tui.SharedState) *HistoryPageModel {
+	m := &HistoryPageModel{sharedState: sharedState}
+	m.loadErr = m.loadRevisions()
+
+	return m
+}
+
+func (m *HistoryPageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HistoryPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "shift+tab":
+			m.revisionIndex = cyclicAdjust(m.revisionIndex-1, 0, max(len(m.revisions)-1, 0))
+		case "down", "tab":
+			m.revisionIndex = cyclicAdjust(m.revisionIndex+1, 0, max(len(m.revisions)-1, 0))
+		case "r":
+			return m.handleRevert()
+		case "esc":
+			m.sharedState.LastPageIndex = tui.HISTORY_PAGE
+			return m, tui.ChangePageCmd(tui.SEARCH_PAGE)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *HistoryPageModel) View() string {
+	rows := []string{"HISTORY  " + m.sharedState.SelectedRecord.Name}
+
+	if len(m.revisions) == 0 && m.loadErr == nil {
+		rows = append(rows, lipgloss.NewStyle().
+			MarginTop(1).
+			Foreground(tui.InactiveColor).
+			Render("  no revisions recorded"))
+	}
+
+	start := max(0, min(m.revisionIndex-maxHistoryRows/2, len(m.revisions)-maxHistoryRows))
+	for i := start; i < min(start+maxHistoryRows, len(m.revisions)); i++ {
+		rev := m.revisions[i]
+		line := fmt.Sprintf(
+			"#%-3d %s  %-12s %s",
+			rev.Number,
+			rev.Time.Format(config.App.DateFormat+" 15:04"),
+			trimRunes(rev.Operator, 12),
+			rev.Action,
+		)
+		if rev.Note != "" {
+			line += " (" + rev.Note + ")"
+		}
+
+		if i == m.revisionIndex {
+			rows = append(rows, "> "+line)
+		} else {
+			rows = append(rows, lipgloss.NewStyle().Foreground(tui.InactiveColor).Render("  "+line))
+		}
+	}
+
+	if len(m.revisions) > 0 {
+		rows[1] = lipgloss.NewStyle().MarginTop(1).Render(rows[1])
+		rows = append(rows, m.renderChanges(m.revisions[m.revisionIndex]))
+	}
+
+	rows = append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("r revert to selected revision • esc back"))
+
+	if m.loadErr != nil {
+		rows = append(rows, tui.RenderError(m.loadErr))
+	} else if m.sharedState.Result != nil {
+		rows = append(rows, tui.RenderResult(m.sharedState.Result))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		MarginLeft(3).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m *HistoryPageModel) renderChanges(rev history.Revision) string {
+	if len(rev.Changes) == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(rev.Changes))
+	for i, change := range rev.Changes {
+		lines[i] = fmt.Sprintf(
+			"%s %s → %s",
+			changeFieldStyle.Render(change.Field),
+			lipgloss.NewStyle().Foreground(tui.InactiveColor).Render(emptyAsDash(change.Old)),
+			emptyAsDash(change.New),
+		)
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		Border(tui.BorderStyle).
+		BorderForeground(tui.InactiveColor).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func emptyAsDash(str string) string {
+	if str == "" {
+		return "-"
+	}
+	return str
+}
+
+func (m *HistoryPageModel) handleRevert() (tea.Model, tea.Cmd) {
+	if len(m.revisions) == 0 {
+		return m, nil
+	}
+
+	record, err := m.sharedState.Service.RevertRecord(m.sharedState.User, m.revisions[m.revisionIndex])
+	if err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	m.sharedState.SelectedRecord = record
+	m.revisionIndex = 0
+
+	if err := m.loadRevisions(); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	return m, nil
+}
+
+func (m *HistoryPageModel) loadRevisions() error {
+	revisions, err := m.sharedState.Service.RecordHistory(m.sharedState.User, m.sharedState.SelectedRecord)
+	if err != nil {
+		return err
+	}
+
+	m.revisions = revisions
+	m.revisionIndex = min(m.revisionIndex, max(len(m.revisions)-1, 0))
+
+	return nil
+}
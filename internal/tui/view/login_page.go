@@ -0,0 +1,187 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	loginNameIndex = iota
+	loginPINIndex
+	loginConfirmIndex
+)
+
+const maxPINChars = 12
+
+type LoginPageModel struct {
+	nameInput    textinput.Model
+	pinInput     textinput.Model
+	confirmInput textinput.Model
+	fieldIndex   int
+
+	// setupMode is set when no users exist yet, the first login creates one
+	setupMode  bool
+	lockedUser string
+	err        error
+
+	sharedState *tui.SharedState
+}
+
+// NewLoginPageModel returns the login page. lockedUser is the operator whose
+// session was locked, if any, and is filled in as the name.
+func NewLoginPageModel(sharedState *tui.SharedState, lockedUser string) *LoginPageModel {
+	m := &LoginPageModel{}
+
+	m.nameInput = makeTextInput(true, config.NAME)
+	m.pinInput = makePINInput()
+	m.confirmInput = makePINInput()
+
+	m.sharedState = sharedState
+	m.lockedUser = lockedUser
+
+	users, err := sharedState.Users.List()
+	if err != nil {
+		m.err = err
+	}
+	m.setupMode = err == nil && len(users) == 0
+
+	if lockedUser != "" {
+		m.nameInput.SetValue(lockedUser)
+		m.fieldIndex = loginPINIndex
+		m.updateFocus()
+	}
+
+	return m
+}
+
+func makePINInput() textinput.Model {
+	t := makeTextInput(false)
+	t.EchoMode = textinput.EchoPassword
+	t.EchoCharacter = '*'
+	t.CharLimit = maxPINChars
+	return t
+}
+
+func (m *LoginPageModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *LoginPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab", "down":
+			m.fieldIndex = cyclicAdjust(m.fieldIndex+1, loginNameIndex, m.lastFieldIndex())
+			return m, m.updateFocus()
+		case "shift+tab", "up":
+			m.fieldIndex = cyclicAdjust(m.fieldIndex-1, loginNameIndex, m.lastFieldIndex())
+			return m, m.updateFocus()
+		case "enter":
+			if m.fieldIndex < m.lastFieldIndex() {
+				m.fieldIndex++
+				return m, m.updateFocus()
+			}
+			return m.handleSubmit()
+		}
+	}
+
+	cmd := make([]tea.Cmd, 3)
+
+	m.nameInput, cmd[0] = m.nameInput.Update(msg)
+	m.pinInput, cmd[1] = m.pinInput.Update(msg)
+	m.confirmInput, cmd[2] = m.confirmInput.Update(msg)
+
+	return m, tea.Batch(cmd...)
+}
+
+func (m *LoginPageModel) View() string {
+	var title string
+	switch {
+	case m.setupMode:
+		title = "No users yet, create the first admin"
+	case m.lockedUser != "":
+		title = fmt.Sprintf("Locked, signed in as %s", m.lockedUser)
+	default:
+		title = "Sign in"
+	}
+
+	rows := []string{
+		lipgloss.NewStyle().MarginLeft(2).MarginBottom(1).Render(title),
+		makeTextField("NAME", m.nameInput.View(), m.fieldIndex == loginNameIndex),
+		makeTextField("PIN", m.pinInput.View(), m.fieldIndex == loginPINIndex),
+	}
+
+	if m.setupMode {
+		rows = append(rows, makeTextField("CONFIRM PIN", m.confirmInput.View(), m.fieldIndex == loginConfirmIndex))
+	}
+
+	if m.err != nil {
+		rows = append(rows, tui.RenderError(m.err))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m *LoginPageModel) handleSubmit() (tea.Model, tea.Cmd) {
+	name := m.nameInput.Value()
+	pin := m.pinInput.Value()
+
+	if m.setupMode {
+		if err := auth.ValidatePIN(pin); err != nil {
+			return m.fail(err)
+		}
+		if pin != m.confirmInput.Value() {
+			return m.fail(fmt.Errorf("PINs do not match"))
+		}
+		if err := m.sharedState.Users.Add(name, pin, auth.RoleAdmin); err != nil {
+			return m.fail(err)
+		}
+	}
+
+	user, err := m.sharedState.Users.Authenticate(name, pin)
+	if err != nil {
+		return m.fail(err)
+	}
+
+	return m, tui.LoginCmd(user)
+}
+
+func (m *LoginPageModel) fail(err error) (tea.Model, tea.Cmd) {
+	m.err = err
+	m.pinInput.SetValue("")
+	m.confirmInput.SetValue("")
+	m.fieldIndex = loginPINIndex
+	return m, m.updateFocus()
+}
+
+func (m *LoginPageModel) lastFieldIndex() int {
+	if m.setupMode {
+		return loginConfirmIndex
+	}
+	return loginPINIndex
+}
+
+func (m *LoginPageModel) updateFocus() tea.Cmd {
+	m.nameInput.Blur()
+	m.pinInput.Blur()
+	m.confirmInput.Blur()
+
+	switch m.fieldIndex {
+	case loginNameIndex:
+		return m.nameInput.Focus()
+	case loginPINIndex:
+		return m.pinInput.Focus()
+	case loginConfirmIndex:
+		return m.confirmInput.Focus()
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package view
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bgics/pmjay-go/config"
 	"github.com/bgics/pmjay-go/internal/tui"
@@ -16,7 +17,18 @@ type SearchPageModel struct {
 	searchInput   textinput.Model
 	recordIndex   int
 	searchResults []model.FormData
-	sharedState   *tui.SharedState
+
+	// undoRecord is the last deleted record while its undo toast is shown
+	undoRecord *model.FormData
+	undoSeq    int
+
+	dialog *dialogModel
+
+	sharedState *tui.SharedState
+}
+
+type undoExpiredMsg struct {
+	seq int
 }
 
 func NewSearchPageView(sharedState *tui.SharedState) *SearchPageModel {
@@ -33,7 +45,20 @@ func (m *SearchPageModel) Init() tea.Cmd {
 
 // TODO: refactor update and views
 func (m *SearchPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
+	case undoExpiredMsg:
+		if msg.seq == m.undoSeq {
+			m.undoRecord = nil
+		}
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "shift+tab":
@@ -41,41 +66,103 @@ func (m *SearchPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down", "tab":
 			m.recordIndex = cyclicAdjust(m.recordIndex+1, 0, max(len(m.searchResults)-1, 0))
 		case "enter":
+			return m.openRecord()
+		case "ctrl+r":
 			if len(m.searchResults) > 0 {
 				m.sharedState.SelectedRecord = m.searchResults[m.recordIndex]
 				m.sharedState.LastPageIndex = tui.SEARCH_PAGE
-				return m, tui.ChangePageCmd(tui.FORM_PAGE)
+				return m, tui.ChangePageCmd(tui.HISTORY_PAGE)
 			}
 
 			return m, nil
+		case "ctrl+b":
+			return m.handleAddSibling()
 		case "delete":
-			return m.handleRemoveRecord()
+			return m.confirmRemoveRecord()
+		case "ctrl+z":
+			return m.handleUndoRemove()
 		case "esc":
 			m.sharedState.LastPageIndex = tui.SEARCH_PAGE
 			return m, tui.ChangePageCmd(tui.START_PAGE)
 		}
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
 	}
 
 	var cmd tea.Cmd
 	m.searchInput, cmd = m.searchInput.Update(msg)
 
-	searchValue := strings.TrimSpace(m.searchInput.Value())
-	if len(searchValue) > 0 {
-		results, err := m.sharedState.Store.GetRecordsByName(searchValue)
-		if err != nil {
-			return m, tui.ErrorCmd(err)
-		} else {
-			m.searchResults = results
+	if err := m.refreshResults(); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	return m, cmd
+}
+
+func (m *SearchPageModel) openRecord() (tea.Model, tea.Cmd) {
+	if len(m.searchResults) == 0 {
+		return m, nil
+	}
+
+	m.sharedState.SelectedRecord = m.searchResults[m.recordIndex]
+	m.sharedState.LastPageIndex = tui.SEARCH_PAGE
+	return m, tui.ChangePageCmd(tui.FORM_PAGE)
+}
+
+// handleMouse selects the result clicked, opening it if it already was.
+// The wheel moves through the results.
+func (m *SearchPageModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.recordIndex = max(m.recordIndex-1, 0)
+		return m, nil
+	case tea.MouseButtonWheelDown:
+		m.recordIndex = min(m.recordIndex+1, max(len(m.searchResults)-1, 0))
+		return m, nil
+	}
+
+	for i := range m.searchResults {
+		if !m.sharedState.Zones.Hit(resultZone(i), msg) {
+			continue
 		}
-	} else {
+		if i == m.recordIndex {
+			return m.openRecord()
+		}
+		m.recordIndex = i
+		break
+	}
+
+	return m, nil
+}
+
+func resultZone(index int) string {
+	return fmt.Sprintf("search.result.%d", index)
+}
+
+func (m *SearchPageModel) refreshResults() error {
+	searchValue := strings.TrimSpace(m.searchInput.Value())
+	if len(searchValue) == 0 {
 		m.searchResults = nil
+		return nil
 	}
 
-	return m, cmd
+	results, err := m.sharedState.Service.SearchRecords(m.sharedState.User, searchValue)
+	if err != nil {
+		return err
+	}
+
+	m.searchResults = results
+	m.recordIndex = min(m.recordIndex, max(len(m.searchResults)-1, 0))
+
+	return nil
 }
 
 // TODO: refactor the style uses in this function
 func (m *SearchPageModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
 	var output strings.Builder
 
 	output.WriteString("\n")
@@ -90,11 +177,20 @@ func (m *SearchPageModel) View() string {
 	for i, result := range m.searchResults {
 		style := lipgloss.NewStyle().MarginTop(1)
 
+		// siblings after the first of a multiple birth are shown under it
+		name := result.Name
+		if i > 0 && result.SiblingGroup != "" && m.searchResults[i-1].SiblingGroup == result.SiblingGroup {
+			style = style.MarginTop(0)
+			name = "└ " + name
+		}
+
 		if i == m.recordIndex {
-			searchResultViews = append(searchResultViews, style.Render("> "+result.Name))
+			name = "> " + name
 		} else {
-			searchResultViews = append(searchResultViews, style.Foreground(tui.InactiveColor).Render("  "+result.Name))
+			style = style.Foreground(tui.InactiveColor)
+			name = "  " + name
 		}
+		searchResultViews = append(searchResultViews, style.Render(m.sharedState.Zones.Mark(resultZone(i), name)))
 	}
 
 	searchResults := lipgloss.NewStyle().
@@ -105,11 +201,18 @@ func (m *SearchPageModel) View() string {
 		),
 	)
 
-	var errMsg string
-	if err := m.sharedState.Error; err != nil {
-		errMsg = tui.ErrStyle.Render(fmt.Sprintf("[ERROR] %v", err))
+	keyHints := lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("enter open • ctrl+b add sibling • delete remove • ctrl+r history • esc back")
+
+	var toast string
+	if m.undoRecord != nil {
+		toast = tui.ToastStyle.Render(fmt.Sprintf("Deleted %s, ctrl+z to undo", m.undoRecord.Name))
 	}
 
+	errMsg := tui.RenderResult(m.sharedState.Result)
+
 	output.WriteString(
 		lipgloss.NewStyle().
 			MarginLeft(3).
@@ -118,6 +221,8 @@ func (m *SearchPageModel) View() string {
 					lipgloss.Left,
 					searchInput,
 					searchResults,
+					keyHints,
+					toast,
 					errMsg,
 				),
 			),
@@ -126,11 +231,47 @@ func (m *SearchPageModel) View() string {
 	return output.String()
 }
 
-func (m *SearchPageModel) handleRemoveRecord() (tea.Model, tea.Cmd) {
-	recordName := m.searchResults[m.recordIndex].Name
+// handleAddSibling opens a new form for the next born of the multiple birth
+// of the selected record.
+func (m *SearchPageModel) handleAddSibling() (tea.Model, tea.Cmd) {
+	if len(m.searchResults) == 0 {
+		return m, nil
+	}
+
+	sibling, err := m.sharedState.Service.NewSibling(m.sharedState.User, m.searchResults[m.recordIndex])
+	if err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	m.sharedState.SelectedRecord = sibling
+	m.sharedState.LastPageIndex = tui.SEARCH_PAGE
+	return m, tui.ChangePageCmd(tui.FORM_PAGE)
+}
+
+func (m *SearchPageModel) confirmRemoveRecord() (tea.Model, tea.Cmd) {
+	if len(m.searchResults) == 0 {
+		return m, nil
+	}
+
+	record := m.searchResults[m.recordIndex]
+	m.dialog = newConfirmDialog(
+		"Delete record",
+		fmt.Sprintf("Move %s to the trash? It can be restored from the trash until it is purged.", record.Name),
+		"DELETE",
+		func() tea.Cmd {
+			_, cmd := m.handleRemoveRecord()
+			return cmd
+		},
+	)
 
+	return m, nil
+}
+
+func (m *SearchPageModel) handleRemoveRecord() (tea.Model, tea.Cmd) {
 	if len(m.searchResults) > 0 {
-		if err := m.sharedState.Store.RemoveRecord(recordName); err != nil {
+		record := m.searchResults[m.recordIndex]
+
+		if err := m.sharedState.Service.DeleteRecord(m.sharedState.User, record); err != nil {
 			return m, tui.ErrorCmd(err)
 		}
 
@@ -140,6 +281,33 @@ func (m *SearchPageModel) handleRemoveRecord() (tea.Model, tea.Cmd) {
 		if m.recordIndex < 0 {
 			m.recordIndex = 0
 		}
+
+		m.undoRecord = &record
+		m.undoSeq++
+
+		seq := m.undoSeq
+		return m, tea.Tick(config.UndoTimeout, func(time.Time) tea.Msg {
+			return undoExpiredMsg{seq: seq}
+		})
+	}
+
+	return m, nil
+}
+
+func (m *SearchPageModel) handleUndoRemove() (tea.Model, tea.Cmd) {
+	if m.undoRecord == nil {
+		return m, nil
+	}
+
+	record := *m.undoRecord
+	m.undoRecord = nil
+
+	if err := m.sharedState.Service.RestoreRecord(m.sharedState.User, record); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	if err := m.refreshResults(); err != nil {
+		return m, tui.ErrorCmd(err)
 	}
 
 	return m, nil
@@ -3,25 +3,70 @@ package view
 import (
 	"fmt"
 
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/draft"
 	"github.com/bgics/pmjay-go/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	choices = []string{"New Patient", "Search Records"}
+	choices = []string{"New Patient", "Search Records", "Audit Log", "Trash", "Duplicates", "Backups"}
 )
 
 type StartPageModel struct {
 	choiceIndex int
+	dialog      *dialogModel
 	sharedState *tui.SharedState
 }
 
 func NewStartPageModel(sharedState *tui.SharedState) *StartPageModel {
-	return &StartPageModel{
+	m := &StartPageModel{
 		choiceIndex: 0,
 		sharedState: sharedState,
 	}
+
+	if d := sharedState.Draft; d != nil {
+		m.dialog = m.makeRecoverDialog(*d)
+	}
+
+	return m
+}
+
+// makeRecoverDialog offers to reopen a form that was left unsaved when the
+// app last exited.
+func (m *StartPageModel) makeRecoverDialog(d draft.Draft) *dialogModel {
+	recoverDraft := func() tea.Cmd {
+		m.sharedState.LastPageIndex = tui.START_PAGE
+		return tui.ChangePageCmd(tui.FORM_PAGE)
+	}
+	discardDraft := func() tea.Cmd {
+		m.sharedState.Draft = nil
+		if err := m.sharedState.Service.DiscardDraft(); err != nil {
+			return tui.ErrorCmd(err)
+		}
+		return nil
+	}
+
+	name := d.Record.Name
+	if name == "" {
+		name = "a new patient"
+	}
+
+	dialog := newChoiceDialog(
+		"Unsaved form",
+		fmt.Sprintf("The form for %s was not saved before the app closed. It was last edited by %s at %s.",
+			name, d.Operator, d.SavedAt.Format(config.App.DateFormat+" 15:04")),
+		dialogOption{label: "RECOVER", key: "r", action: recoverDraft},
+		dialogOption{label: "DISCARD", key: "d", action: discardDraft},
+	)
+	// cancel keeps the draft for the next start
+	dialog.onCancel = func() tea.Cmd {
+		m.sharedState.Draft = nil
+		return nil
+	}
+
+	return dialog
 }
 
 func (m *StartPageModel) Init() tea.Cmd {
@@ -29,6 +74,14 @@ func (m *StartPageModel) Init() tea.Cmd {
 }
 
 func (m *StartPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -37,13 +90,13 @@ func (m *StartPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down", "tab":
 			m.choiceIndex = cyclicAdjust(m.choiceIndex+1, 0, len(choices)-1)
 		case "enter":
-			switch m.choiceIndex {
-			case 0:
-				m.sharedState.LastPageIndex = tui.START_PAGE
-				return m, tui.ChangePageCmd(tui.FORM_PAGE)
-			case 1:
-				m.sharedState.LastPageIndex = tui.START_PAGE
-				return m, tui.ChangePageCmd(tui.SEARCH_PAGE)
+			return m.openChoice()
+		}
+	case tea.MouseMsg:
+		for i := range choices {
+			if m.sharedState.Zones.Hit(choiceZone(i), msg) {
+				m.choiceIndex = i
+				return m.openChoice()
 			}
 		}
 	}
@@ -51,19 +104,42 @@ func (m *StartPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+var choicePages = []tui.PageIndex{tui.FORM_PAGE, tui.SEARCH_PAGE, tui.AUDIT_PAGE, tui.TRASH_PAGE, tui.DUPLICATE_PAGE, tui.BACKUP_PAGE}
+
+// openChoice changes to the page of the choice at choiceIndex.
+func (m *StartPageModel) openChoice() (tea.Model, tea.Cmd) {
+	m.sharedState.LastPageIndex = tui.START_PAGE
+	return m, tui.ChangePageCmd(choicePages[m.choiceIndex])
+}
+
+func choiceZone(index int) string {
+	return fmt.Sprintf("start.choice.%d", index)
+}
+
 func (m *StartPageModel) View() string {
-	rows := make([]string, len(choices)+1)
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
+	rows := make([]string, len(choices)+2)
 	for i, choice := range choices {
 		style := lipgloss.NewStyle().MarginTop(2)
 		if i == m.choiceIndex {
-			rows[i] = style.Render("> " + choice)
+			rows[i] = style.Render(m.sharedState.Zones.Mark(choiceZone(i), "> "+choice))
 		} else {
-			rows[i] = style.Render("  " + choice)
+			rows[i] = style.Render(m.sharedState.Zones.Mark(choiceZone(i), "  "+choice))
 		}
 	}
 
-	if err := m.sharedState.Error; err != nil {
-		rows[len(choices)] = tui.ErrStyle.Render(fmt.Sprintf("[ERROR] %v", err))
+	rows[len(choices)] = lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render(fmt.Sprintf("signed in as %s, ctrl+l to lock", m.sharedState.User.Name))
+
+	rows[len(choices)+1] = tui.RenderResult(m.sharedState.Result)
+
+	if hospital := config.App.Hospital.Name; hospital != "" {
+		rows = append([]string{lipgloss.NewStyle().MarginTop(1).Bold(true).Render(hospital)}, rows...)
 	}
 
 	return lipgloss.NewStyle().
@@ -0,0 +1,170 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/bgics/pmjay-go/model"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type TrashPageModel struct {
+	records     []model.FormData
+	recordIndex int
+	loadErr     error
+	dialog      *dialogModel
+	sharedState *tui.SharedState
+}
+
+func NewTrashPageModel(sharedState *tui.SharedState) *TrashPageModel {
+	m := &TrashPageModel{sharedState: sharedState}
+	m.loadErr = m.loadRecords()
+
+	return m
+}
+
+func (m *TrashPageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *TrashPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.dialog != nil && isInput(msg) {
+		cmd, closed := m.dialog.Update(msg, m.sharedState.Zones)
+		if closed {
+			m.dialog = nil
+		}
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "shift+tab":
+			m.recordIndex = cyclicAdjust(m.recordIndex-1, 0, max(len(m.records)-1, 0))
+		case "down", "tab":
+			m.recordIndex = cyclicAdjust(m.recordIndex+1, 0, max(len(m.records)-1, 0))
+		case "r", "enter":
+			return m.handleRestore()
+		case "delete":
+			return m.confirmPurge()
+		case "esc":
+			m.sharedState.LastPageIndex = tui.TRASH_PAGE
+			return m, tui.ChangePageCmd(tui.START_PAGE)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *TrashPageModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.View(m.sharedState.Zones)
+	}
+
+	rows := []string{"TRASH"}
+
+	if len(m.records) == 0 && m.loadErr == nil {
+		rows = append(rows, lipgloss.NewStyle().
+			MarginTop(1).
+			Foreground(tui.InactiveColor).
+			Render("  no deleted records"))
+	}
+
+	for i, record := range m.records {
+		line := fmt.Sprintf(
+			"%-40s deleted %s by %s",
+			trimRunes(record.Name, 40),
+			record.DeletedAt.Format(config.App.DateFormat+" 15:04"),
+			record.DeletedBy,
+		)
+		if record.MergedInto != "" {
+			line += ", merged"
+		}
+
+		style := lipgloss.NewStyle().MarginTop(1)
+		if i == m.recordIndex {
+			rows = append(rows, style.Render("> "+line))
+		} else {
+			rows = append(rows, style.Foreground(tui.InactiveColor).Render("  "+line))
+		}
+	}
+
+	rows = append(rows, lipgloss.NewStyle().
+		MarginTop(2).
+		Foreground(tui.InactiveColor).
+		Render("enter/r restore • delete purge for good • esc back"))
+
+	if m.loadErr != nil {
+		rows = append(rows, tui.RenderError(m.loadErr))
+	} else if m.sharedState.Result != nil {
+		rows = append(rows, tui.RenderResult(m.sharedState.Result))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(1).
+		MarginLeft(3).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m *TrashPageModel) handleRestore() (tea.Model, tea.Cmd) {
+	if len(m.records) == 0 {
+		return m, nil
+	}
+
+	if err := m.sharedState.Service.RestoreRecord(m.sharedState.User, m.records[m.recordIndex]); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	return m, m.reload()
+}
+
+func (m *TrashPageModel) confirmPurge() (tea.Model, tea.Cmd) {
+	if len(m.records) == 0 {
+		return m, nil
+	}
+
+	m.dialog = newConfirmDialog(
+		"Purge record",
+		fmt.Sprintf("Delete %s for good? This cannot be undone.", m.records[m.recordIndex].Name),
+		"PURGE",
+		func() tea.Cmd {
+			_, cmd := m.handlePurge()
+			return cmd
+		},
+	)
+
+	return m, nil
+}
+
+func (m *TrashPageModel) handlePurge() (tea.Model, tea.Cmd) {
+	if len(m.records) == 0 {
+		return m, nil
+	}
+
+	if err := m.sharedState.Service.PurgeRecord(m.sharedState.User, m.records[m.recordIndex]); err != nil {
+		return m, tui.ErrorCmd(err)
+	}
+
+	return m, m.reload()
+}
+
+func (m *TrashPageModel) reload() tea.Cmd {
+	if err := m.loadRecords(); err != nil {
+		return tui.ErrorCmd(err)
+	}
+	return nil
+}
+
+func (m *TrashPageModel) loadRecords() error {
+	records, err := m.sharedState.Service.DeletedRecords(m.sharedState.User)
+	if err != nil {
+		return err
+	}
+
+	m.records = records
+	m.recordIndex = min(m.recordIndex, max(len(m.records)-1, 0))
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package view
+
+import (
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/internal/tui"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxPassphraseChars = 128
+
+// UnlockPageModel asks for the passphrase the data is encrypted with.
+type UnlockPageModel struct {
+	passphraseInput textinput.Model
+	err             error
+	sharedState     *tui.SharedState
+}
+
+func NewUnlockPageModel(sharedState *tui.SharedState) *UnlockPageModel {
+	m := &UnlockPageModel{sharedState: sharedState}
+
+	m.passphraseInput = makeTextInput(true)
+	m.passphraseInput.EchoMode = textinput.EchoPassword
+	m.passphraseInput.EchoCharacter = '*'
+	m.passphraseInput.CharLimit = maxPassphraseChars
+
+	return m
+}
+
+func (m *UnlockPageModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *UnlockPageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			key, err := crypt.LoadKey(config.App.KeyFile(), m.passphraseInput.Value())
+			if err != nil {
+				m.err = err
+				m.passphraseInput.SetValue("")
+				return m, nil
+			}
+
+			m.sharedState.Service.SetKey(key)
+			return m, tui.UnlockCmd()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.passphraseInput, cmd = m.passphraseInput.Update(msg)
+
+	return m, cmd
+}
+
+func (m *UnlockPageModel) View() string {
+	rows := []string{
+		lipgloss.NewStyle().MarginLeft(2).MarginBottom(1).Render("Patient data is encrypted, enter the passphrase"),
+		makeTextField("PASSPHRASE", m.passphraseInput.View(), true),
+	}
+
+	if m.err != nil {
+		rows = append(rows, tui.RenderError(m.err))
+	}
+
+	return lipgloss.NewStyle().
+		MarginTop(2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
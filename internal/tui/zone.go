@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// zoneBase keeps the numbers of zone marks clear of real escape sequences
+const zoneBase = 9000
+
+// zoneMark is a mark left in a view around a zone. Being an escape sequence
+// it takes no room when lipgloss measures the view.
+var zoneMark = regexp.MustCompile("\x1b\\[(\\d+)z")
+
+// Rect is where a zone is on the screen, in cells.
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+func (r Rect) Contains(x, y int) bool {
+	return r.X <= x && x < r.X+r.Width && r.Y <= y && y < r.Y+r.Height
+}
+
+// Zones finds where parts of a view end up on the screen, to tell what a
+// mouse click is on. A page marks the parts while it renders, then the whole
+// view is scanned for the marks, which also drops them.
+type Zones struct {
+	ids   map[string]int
+	names []string
+	rects map[string]Rect
+	mu    sync.Mutex
+}
+
+func NewZones() *Zones {
+	return &Zones{ids: make(map[string]int), rects: make(map[string]Rect)}
+}
+
+// Mark marks str as the zone id. A zone is the box from the start of the
+// first line of str to the end of its last line.
+func (z *Zones) Mark(id, str string) string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	n, found := z.ids[id]
+	if !found {
+		n = zoneBase + len(z.names)
+		z.ids[id] = n
+		z.names = append(z.names, id)
+	}
+
+	mark := "\x1b[" + strconv.Itoa(n) + "z"
+	return mark + str + mark
+}
+
+// Scan records where the zones marked in view are and returns view without
+// the marks. Zones not in view are forgotten.
+func (z *Zones) Scan(view string) string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	type point struct{ x, y int }
+	starts := make(map[string]point)
+	rects := make(map[string]Rect)
+
+	lines := strings.Split(view, "\n")
+	for y, line := range lines {
+		var clean strings.Builder
+		rest := line
+		for {
+			loc := zoneMark.FindStringSubmatchIndex(rest)
+			if loc == nil {
+				clean.WriteString(rest)
+				break
+			}
+
+			clean.WriteString(rest[:loc[0]])
+			n, _ := strconv.Atoi(rest[loc[2]:loc[3]])
+			rest = rest[loc[1]:]
+
+			if n < zoneBase || n-zoneBase >= len(z.names) {
+				continue
+			}
+			id := z.names[n-zoneBase]
+			x := ansi.StringWidth(clean.String())
+
+			start, open := starts[id]
+			if !open {
+				starts[id] = point{x, y}
+				continue
+			}
+			delete(starts, id)
+			rects[id] = Rect{
+				X:      min(start.x, x),
+				Y:      start.y,
+				Width:  max(start.x, x) - min(start.x, x),
+				Height: y - start.y + 1,
+			}
+		}
+		lines[y] = clean.String()
+	}
+
+	z.rects = rects
+	return strings.Join(lines, "\n")
+}
+
+// Hit reports whether msg is a press of the left button inside zone id.
+func (z *Zones) Hit(id string, msg tea.MouseMsg) bool {
+	_, _, ok := z.Pos(id, msg)
+	return ok && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// Pos returns where msg is inside zone id, from its top left corner.
+func (z *Zones) Pos(id string, msg tea.MouseMsg) (x, y int, ok bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	r, found := z.rects[id]
+	if !found || !r.Contains(msg.X, msg.Y) {
+		return 0, 0, false
+	}
+	return msg.X - r.X, msg.Y - r.Y, true
+}
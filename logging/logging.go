@@ -0,0 +1,119 @@
+// Package logging writes the app's structured log to a size-rotated file, so
+// problems can be looked into after the fact without writing into the
+// terminal the app draws on.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const (
+	// MaxFileSize is the size the log file grows to before it is rotated
+	MaxFileSize = 1 << 20
+	// Generations is how many rotated files are kept besides the current one
+	Generations = 4
+)
+
+// Setup makes slog, and with it the log package, write to fileStr from level
+// up. Close the returned closer before exiting.
+func Setup(fileStr string, level slog.Level) (io.Closer, error) {
+	w, err := newRotatingFile(fileStr, MaxFileSize, Generations)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log file: %w", err)
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+
+	return w, nil
+}
+
+// rotatingFile appends to a file and renames it to fileStr.1 once it gets
+// bigger than maxSize, shifting older files up to fileStr.<generations>.
+type rotatingFile struct {
+	fileStr     string
+	maxSize     int64
+	generations int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newRotatingFile(fileStr string, maxSize int64, generations int) (*rotatingFile, error) {
+	r := &rotatingFile{fileStr: fileStr, maxSize: maxSize, generations: generations}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.fileStr, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// rotate shifts the files up and starts a new one. If they cannot be
+// renamed the current file is reopened, so logging goes on and the rotation
+// is tried again on a later write; only failing to reopen is an error.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err == nil {
+		_ = r.shift()
+	}
+
+	return r.open()
+}
+
+// shift renames fileStr to fileStr.1 and the older files one up, stopping at
+// the first that cannot be renamed.
+func (r *rotatingFile) shift() error {
+	for i := r.generations - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", r.fileStr, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", r.fileStr, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.Rename(r.fileStr, r.fileStr+".1")
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
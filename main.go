@@ -1,29 +1,80 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/internal/cli"
 	"github.com/bgics/pmjay-go/internal/tui/starter"
+	"github.com/bgics/pmjay-go/logging"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	p := tea.NewProgram(starter.NewModel(), tea.WithAltScreen())
+	os.Exit(run())
+}
+
+// run runs the app or a command and returns the exit code. It is apart from
+// main so its deferred calls run before the exit.
+func run() int {
+	fs := flag.NewFlagSet("pmjay", flag.ExitOnError)
+	configPath := fs.String("config", "", "settings file to use instead of "+config.SettingsFileName+" next to the executable or in the user config directory")
+	fs.Usage = func() {
+		_ = cli.Run([]string{"help"})
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:])
+
+	if err := config.Load(*configPath); err != nil {
+		fmt.Printf("error: %v\n", err)
+		return 1
+	}
+
+	logFile, err := logging.Setup(config.App.LogFile(), config.App.Level())
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := logFile.Close(); err != nil {
+			fmt.Printf("error closing log file: %v\n", err)
+		}
+	}()
+
+	if fs.NArg() > 0 {
+		slog.Info("command started", "args", fs.Args())
+		if err := cli.Run(fs.Args()); err != nil {
+			slog.Error("command failed", "args", fs.Args(), "err", err)
+			fmt.Printf("error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	slog.Info("app started", "config", config.App.FilePath, "data_dir", config.App.DataDir)
+
+	p := tea.NewProgram(starter.NewModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
 	exitModel, err := p.Run()
 	if err != nil {
+		slog.Error("app failed", "err", err)
 		fmt.Printf("error occured: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	typedExitModel, ok := exitModel.(*starter.Model)
 	if !ok {
 		fmt.Println("failed to assert exit model type")
-		os.Exit(1)
+		return 1
 	}
 
 	if err := typedExitModel.ExitError; err != nil {
+		slog.Error("app exited with error", "err", err)
 		fmt.Printf("model exited with error: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+
+	return 0
 }
@@ -1,20 +1,225 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
+// Gender is the one letter code of a gender, as stored and printed after
+// the name.
 type Gender string
 
 const (
-	Male   = "M"
-	Female = "F"
+	Male        Gender = "M"
+	Female      Gender = "F"
+	Transgender Gender = "T"
+	// Undetermined is for neonates whose sex cannot be assigned at birth,
+	// such as with ambiguous genitalia
+	Undetermined Gender = "U"
 )
 
+// Genders are the genders a record can have, in the order they are offered.
+var Genders = []Gender{Male, Female, Transgender, Undetermined}
+
+var genderLabels = map[Gender]string{
+	Male:         "Male",
+	Female:       "Female",
+	Transgender:  "Transgender",
+	Undetermined: "Undetermined",
+}
+
+// genderAliases are other ways a gender was written, in older records or
+// files edited by hand.
+var genderAliases = map[string]Gender{
+	"boy":         Male,
+	"girl":        Female,
+	"tg":          Transgender,
+	"third":       Transgender,
+	"other":       Transgender,
+	"o":           Transgender,
+	"ambiguous":   Undetermined,
+	"indetermine": Undetermined,
+	"intersex":    Undetermined,
+	"i":           Undetermined,
+}
+
+func (g Gender) Label() string {
+	if label, ok := genderLabels[g]; ok {
+		return label
+	}
+	return string(g)
+}
+
+// ParseGender reads a gender from its code, its label or one of the older
+// ways it was written, in any case.
+func ParseGender(str string) (Gender, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	for _, g := range Genders {
+		if str == strings.ToLower(string(g)) || str == strings.ToLower(g.Label()) {
+			return g, nil
+		}
+	}
+	if g, ok := genderAliases[str]; ok {
+		return g, nil
+	}
+	return "", fmt.Errorf("unknown gender %q", str)
+}
+
 type FormData struct {
-	Name            string
-	Address         string
-	Diagnosis       string
+	ID   string
+	Name string
+	// Address is AddressParts joined, or the address as typed for records
+	// saved before addresses had parts
+	Address      string
+	AddressParts Address
+	Diagnosis    string
+	// DiagnosisCode is the ICD-10 code the diagnosis was picked as, if any
+	DiagnosisCode   string
+	Packages        []Package
 	Gender          Gender
 	Date            time.Time
 	DateOfBirth     time.Time
 	DateOfAdmission time.Time
+
+	// SiblingGroup is the ID of the first record of a multiple birth, shared
+	// by all its siblings. BirthOrder is 1 for the first born of them and 0
+	// for a single birth.
+	SiblingGroup string
+	BirthOrder   int
+
+	UpdatedBy      string
+	UpdatedAt      time.Time
+	FirstPrintedAt time.Time
+
+	DeletedBy string
+	DeletedAt time.Time
+	// MergedInto is the ID of the record a deleted duplicate was merged into
+	MergedInto string
+}
+
+// Address is an address in parts, as filled in from the pincode directory.
+type Address struct {
+	// Line is the house and village or locality
+	Line     string
+	Block    string
+	District string
+	State    string
+	PIN      string
+}
+
+// String joins the parts in the order they are printed. A block named like
+// its district is written once.
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.Line, a.Block, a.District, a.State} {
+		part = strings.TrimSpace(part)
+		if part != "" && (len(parts) == 0 || !strings.EqualFold(parts[len(parts)-1], part)) {
+			parts = append(parts, part)
+		}
+	}
+
+	str := strings.Join(parts, ", ")
+	if pin := strings.TrimSpace(a.PIN); pin != "" {
+		str = strings.TrimPrefix(str+" - "+pin, " - ")
+	}
+	return str
+}
+
+// Package is an HBP package claimed for an admission. Rate is the amount in
+// rupees when it was picked, so a later change to the package master leaves
+// past claims as they were.
+type Package struct {
+	Code string
+	Rate int
+}
+
+// PackageTotal is the amount of all the packages claimed, in rupees.
+func (fd FormData) PackageTotal() int {
+	total := 0
+	for _, p := range fd.Packages {
+		total += p.Rate
+	}
+	return total
+}
+
+// birthOrderNumerals are how the birth order is written after the name, as
+// in "B/O Rekha (II)".
+var birthOrderNumerals = []string{"I", "II", "III", "IV", "V", "VI"}
+
+// BirthOrderName is name with the numeral of order after it, in place of any
+// numeral it already has. It is name without one if order is 0.
+func BirthOrderName(name string, order int) string {
+	name = strings.TrimSpace(name)
+	for _, numeral := range birthOrderNumerals {
+		if base, found := strings.CutSuffix(name, "("+numeral+")"); found {
+			name = strings.TrimSpace(base)
+			break
+		}
+	}
+
+	if order <= 0 {
+		return name
+	}
+	numeral := strconv.Itoa(order)
+	if order <= len(birthOrderNumerals) {
+		numeral = birthOrderNumerals[order-1]
+	}
+	return fmt.Sprintf("%s (%s)", name, numeral)
+}
+
+// Sibling is a new record for the next born of the multiple birth of fd,
+// with its order, the same dates and address, and nothing else filled in.
+// The group is started by fd if it has none.
+func (fd FormData) Sibling(order int) FormData {
+	group := fd.SiblingGroup
+	if group == "" {
+		group = fd.ID
+	}
+
+	return FormData{
+		Name:            BirthOrderName(fd.Name, order),
+		Address:         fd.Address,
+		AddressParts:    fd.AddressParts,
+		Gender:          fd.Gender,
+		Date:            fd.Date,
+		DateOfBirth:     fd.DateOfBirth,
+		DateOfAdmission: fd.DateOfAdmission,
+		SiblingGroup:    group,
+		BirthOrder:      order,
+	}
+}
+
+// GroupSiblings moves the siblings of a multiple birth in records next to the
+// first of them, in birth order. The other records keep their order.
+func GroupSiblings(records []FormData) []FormData {
+	var output []FormData
+	done := make(map[string]bool)
+	for _, record := range records {
+		if record.SiblingGroup == "" {
+			output = append(output, record)
+			continue
+		}
+		if done[record.SiblingGroup] {
+			continue
+		}
+		done[record.SiblingGroup] = true
+
+		start := len(output)
+		for _, sibling := range records {
+			if sibling.SiblingGroup == record.SiblingGroup {
+				output = append(output, sibling)
+			}
+		}
+		slices.SortStableFunc(output[start:], func(a, b FormData) int {
+			return a.BirthOrder - b.BirthOrder
+		})
+	}
+	return output
+}
+
+func (fd FormData) IsDeleted() bool {
+	return !fd.DeletedAt.IsZero()
 }
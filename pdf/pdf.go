@@ -1,13 +1,16 @@
 package pdf
 
 import (
+	"bytes"
 	"fmt"
-	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/bgics/pmjay-go/assets"
 	"github.com/bgics/pmjay-go/config"
 	"github.com/bgics/pmjay-go/model"
+	"github.com/bgics/pmjay-go/validate"
 	"github.com/phpdave11/gofpdf"
 )
 
@@ -17,20 +20,39 @@ type textLine struct {
 	y    float64
 }
 
-func PrintPDF(filename string) error {
-	cmd := exec.Command(config.ExeName, filename)
-	return cmd.Run()
-}
-
 func GeneratePDF(outFileStr string, fd model.FormData, numDays int) error {
-	pdf := gofpdf.New(gofpdf.OrientationPortrait, gofpdf.UnitMillimeter, gofpdf.PageSizeA4, config.FontDirStr)
+	fontJSON, err := assets.ReadFile(config.App.AssetsDir, config.FontConfig.FileStr)
+	if err != nil {
+		return err
+	}
+	fontZ, err := assets.ReadFile(config.App.AssetsDir, config.FontConfig.ZFileStr)
+	if err != nil {
+		return err
+	}
+	template, err := assets.ReadFile(config.App.AssetsDir, config.App.Template)
+	if err != nil {
+		return err
+	}
+
+	pdf := gofpdf.New(gofpdf.OrientationPortrait, gofpdf.UnitMillimeter, gofpdf.PageSizeA4, "")
+
+	if hospital := config.App.Hospital; hospital.Name != "" {
+		pdf.SetAuthor(hospital.Name, true)
+	}
+	pdf.SetTitle(fd.Name, true)
 
-	pdf.AddFont(config.FontConfig.FamilyStr, config.FontConfig.StyleStr, config.FontConfig.FileStr)
+	pdf.AddFontFromBytes(config.FontConfig.FamilyStr, config.FontConfig.StyleStr, fontJSON, fontZ)
 	pdf.SetFont(config.FontConfig.FamilyStr, config.FontConfig.StyleStr, config.FontConfig.Size)
 
+	templateOptions := gofpdf.ImageOptions{ImageType: imageType(config.App.Template)}
+	pdf.RegisterImageOptionsReader(config.App.Template, templateOptions, bytes.NewReader(template))
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("cannot load template %s: %w", config.App.Template, err)
+	}
+
 	for range numDays {
 		pdf.AddPage()
-		pdf.Image(config.TemplateFileStr, 0, 0, config.A4WidthMM, config.A4HeightMM, false, "", 0, "")
+		pdf.ImageOptions(config.App.Template, 0, 0, config.A4WidthMM, config.A4HeightMM, false, templateOptions, 0, "")
 
 		textLines, err := convertToTextLines(fd)
 		if err != nil {
@@ -47,6 +69,11 @@ func GeneratePDF(outFileStr string, fd model.FormData, numDays int) error {
 	return pdf.OutputFileAndClose(outFileStr)
 }
 
+// imageType is the gofpdf image type of a file, from its extension.
+func imageType(fileStr string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(fileStr), "."))
+}
+
 func convertToTextLines(fd model.FormData) ([]textLine, error) {
 	if fd.Date.Compare(fd.DateOfAdmission) < 0 {
 		return nil, fmt.Errorf("date is before date of admission")
@@ -72,6 +99,10 @@ func convertToTextLines(fd model.FormData) ([]textLine, error) {
 
 	output = append(output, makeAddressTextLines(fd.Address)...)
 
+	if len(fd.Packages) > 0 {
+		output = append(output, makeTextFieldTextLine(validate.PackagesText(fd.Packages), config.PACKAGES))
+	}
+
 	return output, nil
 }
 
@@ -80,11 +111,14 @@ func makeNameTextLine(name string, gender model.Gender) textLine {
 
 	remainingChars := cfg.MaxChars - len(name)
 
+	// the code is one letter, see config.GenderStrLen
+	suffix := "(" + string(gender) + ")"
+
 	var text string
 	if remainingChars > 0 {
-		text = trim(name+strings.Repeat(" ", remainingChars), cfg.MaxChars) + "(" + string(gender) + ")"
+		text = trim(name+strings.Repeat(" ", remainingChars), cfg.MaxChars) + suffix
 	} else {
-		text = trim(name, cfg.MaxChars) + "(" + string(gender) + ")"
+		text = trim(name, cfg.MaxChars) + suffix
 	}
 
 	return textLine{
@@ -109,13 +143,9 @@ func makeAddressTextLines(address string) []textLine {
 
 	cfgKeys := [3]config.FieldName{config.ADDRESS1, config.ADDRESS2, config.ADDRESS3}
 
-	for i := range 3 {
-		line := makeTextFieldTextLine(address, cfgKeys[i])
-		output = append(output, line)
-		if len(address) <= len(line.text) {
-			return output
-		}
-		address = strings.TrimPrefix(address, line.text)
+	lines, _ := validate.AddressLines(address)
+	for i, line := range lines {
+		output = append(output, makeTextFieldTextLine(line, cfgKeys[i]))
 	}
 
 	return output
@@ -124,7 +154,7 @@ func makeAddressTextLines(address string) []textLine {
 func makeDateTextLine(date time.Time, cfgKey config.FieldName) textLine {
 	cfg := config.FieldConfig[cfgKey]
 
-	dateString := date.Format("02/01/2006")
+	dateString := date.Format(config.App.DateFormat)
 	return textLine{
 		text: dateString,
 		x:    cfg.X,
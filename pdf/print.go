@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bgics/pmjay-go/config"
+)
+
+// PrintError is a failed print, with what the print command wrote so the
+// cause can be told to the operator.
+type PrintError struct {
+	Command  string
+	Attempts int
+	ExitCode int
+	TimedOut bool
+	NotFound bool
+	Output   string
+	Err      error
+}
+
+func (e *PrintError) Error() string {
+	name := filepath.Base(e.Command)
+
+	var msg string
+	switch {
+	case e.NotFound:
+		return fmt.Sprintf("print command %s not found, check print.command in the settings", name)
+	case e.TimedOut:
+		msg = fmt.Sprintf("printer did not respond within %s", config.App.PrintTimeout())
+	case e.ExitCode > 0:
+		msg = fmt.Sprintf("%s failed with exit code %d", name, e.ExitCode)
+	default:
+		msg = fmt.Sprintf("%s failed: %v", name, e.Err)
+	}
+
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	if line := lastLine(e.Output); line != "" {
+		msg += ": " + line
+	}
+
+	return msg
+}
+
+func (e *PrintError) Unwrap() error {
+	return e.Err
+}
+
+// transient reports whether trying again may help. A missing command will
+// stay missing and a printer that hung once is not waited for again.
+func (e *PrintError) transient() bool {
+	return !e.NotFound && !e.TimedOut && !errors.Is(e.Err, context.Canceled)
+}
+
+// PrintPDF sends filename to the print command, waiting at most the print
+// timeout for each attempt and retrying failures that may be transient.
+func PrintPDF(ctx context.Context, filename string) error {
+	var err *PrintError
+	for attempt := 1; attempt <= max(config.App.Print.Retries, 0)+1; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(config.PrintRetryDelay):
+			}
+		}
+
+		err = runPrintCommand(ctx, filename)
+		if err == nil {
+			return nil
+		}
+		err.Attempts = attempt
+
+		if !err.transient() {
+			break
+		}
+	}
+
+	return err
+}
+
+func runPrintCommand(ctx context.Context, filename string) *PrintError {
+	if timeout := config.App.PrintTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, config.App.Print.Command, printArgs(config.App.Print.Args, filename)...)
+	// print drivers may leave child processes holding the output open after
+	// the command is killed
+	cmd.WaitDelay = config.PrintWaitDelay
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+
+	attrs := []any{
+		"command", cmd.Args,
+		"exit_code", cmd.ProcessState.ExitCode(),
+		"duration", time.Since(start),
+		"output", strings.TrimSpace(output.String()),
+	}
+	if err == nil {
+		slog.Info("printed", attrs...)
+		return nil
+	}
+	slog.Error("print failed", append(attrs, "err", err)...)
+
+	return &PrintError{
+		Command:  config.App.Print.Command,
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+		NotFound: errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist),
+		Output:   output.String(),
+		Err:      err,
+	}
+}
+
+// printArgs replaces "{file}" in args with filename, or appends filename if
+// no argument refers to it.
+func printArgs(args []string, filename string) []string {
+	output := make([]string, 0, len(args)+1)
+	found := false
+	for _, arg := range args {
+		if strings.Contains(arg, "{file}") {
+			found = true
+		}
+		output = append(output, strings.ReplaceAll(arg, "{file}", filename))
+	}
+
+	if !found {
+		output = append(output, filename)
+	}
+
+	return output
+}
+
+// lastLine is the last non-empty line of output, usually the error message.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,312 @@
+// Package pincode keeps a directory of post offices with their block,
+// district, state and PIN, to fill in addresses from. It is imported from the
+// India Post pincode dataset.
+package pincode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field is a part of an address the directory is searched by.
+type Field int
+
+const (
+	Office Field = iota
+	Block
+	District
+	State
+	PIN
+)
+
+var CSVHeader = []string{"Office", "Block", "District", "State", "PIN"}
+
+// columnNames are the names the columns go by in the India Post datasets,
+// in the order of CSVHeader.
+var columnNames = [][]string{
+	{"office", "officename"},
+	{"block", "taluk"},
+	{"district", "districtname"},
+	{"state", "statename"},
+	{"pin", "pincode"},
+}
+
+// optionalColumns may be missing, newer datasets have no taluk.
+var optionalColumns = []Field{Block}
+
+// Entry is one post office.
+type Entry struct {
+	Office   string
+	Block    string
+	District string
+	State    string
+	PIN      string
+}
+
+func (e Entry) get(field Field) string {
+	switch field {
+	case Office:
+		return e.Office
+	case Block:
+		return e.Block
+	case District:
+		return e.District
+	case State:
+		return e.State
+	}
+	return e.PIN
+}
+
+// Directory is the directory in a file. It is read on first use.
+type Directory struct {
+	fileStr string
+	entries []Entry
+	loaded  bool
+	mu      sync.Mutex
+}
+
+func NewDirectory(fileStr string) *Directory {
+	return &Directory{fileStr: fileStr}
+}
+
+// Parse reads a directory in CSV with a header row, either as written by
+// Import or as published by India Post. Post office suffixes such as B.O
+// are dropped and names in capitals are title cased.
+func Parse(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]int, len(columnNames))
+	for i, names := range columnNames {
+		cols[i] = slices.IndexFunc(header, func(col string) bool {
+			return slices.Contains(names, strings.ToLower(strings.TrimSpace(col)))
+		})
+		if cols[i] == -1 && !slices.Contains(optionalColumns, Field(i)) {
+			return nil, fmt.Errorf("missing column %q", CSVHeader[i])
+		}
+	}
+
+	var entries []Entry
+	seen := make(map[Entry]bool)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(f Field) string {
+			if cols[f] == -1 || cols[f] >= len(row) {
+				return ""
+			}
+			value := strings.TrimSpace(row[cols[f]])
+			if strings.EqualFold(value, "NA") {
+				return ""
+			}
+			return titleCase(value)
+		}
+
+		e := Entry{
+			Office:   trimOfficeSuffix(field(Office)),
+			Block:    field(Block),
+			District: field(District),
+			State:    field(State),
+			PIN:      field(PIN),
+		}
+		if len(e.PIN) != 6 || strings.Trim(e.PIN, "0123456789") != "" {
+			return nil, fmt.Errorf("line %d: invalid PIN %q", line, e.PIN)
+		}
+		if e.Office == "" || e.District == "" || e.State == "" {
+			return nil, fmt.Errorf("line %d: office, district and state are required", line)
+		}
+
+		if !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// trimOfficeSuffix drops the kind of post office from its name, which then
+// is the name of the place.
+func trimOfficeSuffix(office string) string {
+	for _, suffix := range []string{" B.O", " S.O", " H.O", " BO", " SO", " HO"} {
+		if len(office) > len(suffix) && strings.EqualFold(office[len(office)-len(suffix):], suffix) {
+			return strings.TrimSpace(office[:len(office)-len(suffix)])
+		}
+	}
+	return office
+}
+
+// titleCase title cases a name written in capitals and leaves others as
+// they are.
+func titleCase(str string) string {
+	if strings.ToUpper(str) != str {
+		return str
+	}
+
+	runes := []rune(strings.ToLower(str))
+	for i, r := range runes {
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && runes[i-1] != '\'' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// Import replaces the directory with the entries read from r, and returns
+// how many there are. The directory is left as it was if r cannot be
+// parsed.
+func (d *Directory) Import(r io.Reader) (int, error) {
+	entries, err := Parse(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(CSVHeader)
+	for _, e := range entries {
+		w.Write([]string{e.Office, e.Block, e.District, e.State, e.PIN})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tmpFileStr := d.fileStr + ".tmp"
+	if err := os.WriteFile(tmpFileStr, buf.Bytes(), 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpFileStr, d.fileStr); err != nil {
+		return 0, err
+	}
+
+	d.entries = entries
+	d.loaded = true
+
+	return len(entries), nil
+}
+
+// Search returns at most limit entries whose field matches query, within
+// the district, state and PIN of within that are set. For the block,
+// district and state only one entry is returned per place. A PIN matches
+// from the start, names where any of their words does.
+func (d *Directory) Search(field Field, query string, within Entry, limit int) ([]Entry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+
+	var prefixed, matched []Entry
+	seen := make(map[Entry]bool)
+	for _, e := range d.entries {
+		if !inside(e, within, field) {
+			continue
+		}
+
+		value := strings.ToLower(e.get(field))
+		isPrefix := strings.HasPrefix(value, query)
+		if !isPrefix && (field == PIN || !strings.Contains(value, " "+query)) {
+			continue
+		}
+
+		// one entry per place above the post office
+		switch field {
+		case Block:
+			e = Entry{Block: e.Block, District: e.District, State: e.State}
+		case District:
+			e = Entry{District: e.District, State: e.State}
+		case State:
+			e = Entry{State: e.State}
+		}
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+
+		if isPrefix {
+			prefixed = append(prefixed, e)
+		} else {
+			matched = append(matched, e)
+		}
+		if len(prefixed) == limit {
+			break
+		}
+	}
+
+	matches := append(prefixed, matched...)
+	return matches[:min(len(matches), limit)], nil
+}
+
+// inside reports whether e is within the district, state and PIN of within
+// that are set, other than field itself. The block is left out as the
+// datasets often have none.
+func inside(e, within Entry, field Field) bool {
+	for _, f := range []Field{District, State, PIN} {
+		want := within.get(f)
+		if f == field || want == "" {
+			continue
+		}
+		if !strings.EqualFold(e.get(f), want) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Directory) load() error {
+	if d.loaded {
+		return nil
+	}
+
+	f, err := os.Open(d.fileStr)
+	if errors.Is(err, fs.ErrNotExist) {
+		d.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := Parse(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.fileStr, err)
+	}
+
+	d.entries = entries
+	d.loaded = true
+	return nil
+}
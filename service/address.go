@@ -0,0 +1,33 @@
+package service
+
+import (
+	"io"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/model"
+	"github.com/bgics/pmjay-go/pincode"
+)
+
+// SearchAddresses returns at most limit post offices of the pincode
+// directory whose field matches query, see pincode.Directory.Search.
+func (s *Service) SearchAddresses(field pincode.Field, query string, within pincode.Entry, limit int) ([]pincode.Entry, error) {
+	return s.pincodes.Search(field, query, within, limit)
+}
+
+// ImportPincodes replaces the pincode directory with the CSV read from r and
+// returns how many post offices it has.
+func (s *Service) ImportPincodes(user auth.User, r io.Reader) (int, error) {
+	err := user.Require(auth.PermManageData)
+
+	var n int
+	if err == nil {
+		n, err = s.pincodes.Import(r)
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionImportPincodes, model.FormData{}, model.FormData{}, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return n, err
+}
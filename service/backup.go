@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/backup"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/history"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// BackupDiff is what restoring a backup would change in the current data.
+type BackupDiff struct {
+	// Added are in the backup but not in the current data
+	Added []model.FormData
+	// Removed are in the current data but not in the backup
+	Removed []model.FormData
+	Changed []RecordChanges
+}
+
+type RecordChanges struct {
+	Record  model.FormData
+	Changes []history.Change
+}
+
+func (d BackupDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (s *Service) ListBackups(user auth.User) ([]backup.Backup, error) {
+	if err := user.Require(auth.PermManageData); err != nil {
+		return nil, err
+	}
+
+	return s.backups.List()
+}
+
+func (s *Service) CreateBackup(user auth.User) (backup.Backup, error) {
+	if err := user.Require(auth.PermManageData); err != nil {
+		return backup.Backup{}, err
+	}
+
+	b, err := s.backups.Create()
+	if auditErr := s.appendBackupAudit(user, audit.ActionBackup, b, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return b, err
+}
+
+// DiffBackup compares the records in b with the current ones.
+func (s *Service) DiffBackup(user auth.User, b backup.Backup) (BackupDiff, error) {
+	if err := user.Require(auth.PermManageData); err != nil {
+		return BackupDiff{}, err
+	}
+
+	if err := backup.Verify(b); err != nil {
+		return BackupDiff{}, err
+	}
+
+	backupRecords, err := s.store.ReadRecordsFile(b.Path)
+	if err != nil {
+		return BackupDiff{}, fmt.Errorf("cannot read backup: %w", err)
+	}
+
+	currentRecords, err := s.store.ReadRecordsFile(config.App.DataFile())
+	if err != nil {
+		return BackupDiff{}, fmt.Errorf("cannot read current data: %w", err)
+	}
+
+	return diffRecords(currentRecords, backupRecords), nil
+}
+
+// RestoreBackup replaces the current data with b. The current data is backed
+// up first, so a restore can be undone by restoring that backup.
+func (s *Service) RestoreBackup(user auth.User, b backup.Backup) error {
+	err := user.Require(auth.PermManageData)
+	if err == nil {
+		err = s.backups.Restore(b)
+	}
+	if err == nil {
+		s.store.Reload()
+	}
+
+	if auditErr := s.appendBackupAudit(user, audit.ActionRestoreBackup, b, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+func (s *Service) appendBackupAudit(user auth.User, action string, b backup.Backup, err error) error {
+	entry := audit.Entry{
+		Operator: user.Name,
+		Action:   action,
+		Backup:   b.Name,
+		Outcome:  audit.Outcome(err),
+	}
+
+	logAction(entry, err)
+
+	if err := s.audit.Append(entry); err != nil {
+		return fmt.Errorf("cannot write audit log: %w", err)
+	}
+
+	return nil
+}
+
+func diffRecords(current, other []model.FormData) BackupDiff {
+	var diff BackupDiff
+
+	currentByID := make(map[string]model.FormData, len(current))
+	for _, record := range current {
+		currentByID[record.ID] = record
+	}
+
+	for _, record := range other {
+		existing, ok := currentByID[record.ID]
+		if !ok {
+			diff.Added = append(diff.Added, record)
+			continue
+		}
+		delete(currentByID, record.ID)
+
+		if changes := history.Diff(existing, record, config.StorageDateFormat); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, RecordChanges{Record: record, Changes: changes})
+		} else if existing.IsDeleted() != record.IsDeleted() {
+			diff.Changed = append(diff.Changed, RecordChanges{
+				Record:  record,
+				Changes: []history.Change{{Field: "Deleted", Old: fmt.Sprint(existing.IsDeleted()), New: fmt.Sprint(record.IsDeleted())}},
+			})
+		}
+	}
+
+	for _, record := range current {
+		if _, ok := currentByID[record.ID]; ok {
+			diff.Removed = append(diff.Removed, record)
+		}
+	}
+
+	return diff
+}
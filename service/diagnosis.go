@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/icd"
+)
+
+// Catalogue is the ICD-10 catalogue diagnoses are picked from.
+func (s *Service) Catalogue() *icd.Catalogue {
+	return s.icd
+}
+
+// PastDiagnoses returns the diagnoses of the records, most used first.
+func (s *Service) PastDiagnoses(user auth.User) ([]icd.Use, error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return nil, err
+	}
+
+	records, err := s.store.GetRecordsByName("")
+	if err != nil {
+		return nil, err
+	}
+
+	return icd.Uses(records), nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"time"
+
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/draft"
+)
+
+// SaveDraft keeps the unsaved form of user, replacing any earlier draft.
+func (s *Service) SaveDraft(user auth.User, d draft.Draft) error {
+	if err := user.Require(auth.PermSave); err != nil {
+		return err
+	}
+
+	d.Operator = user.Name
+	return s.drafts.Save(d)
+}
+
+// Draft returns the form left unsaved when the app last exited, ok is false
+// if there is none.
+func (s *Service) Draft(user auth.User) (d draft.Draft, ok bool, err error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return draft.Draft{}, false, err
+	}
+
+	return s.drafts.Load()
+}
+
+// DiscardDraft removes the draft once it is saved, recovered or not wanted.
+func (s *Service) DiscardDraft() error {
+	return s.drafts.Clear()
+}
+
+// DiscardSavedDraft removes the draft saved at savedAt once its form is
+// saved. A draft saved since, e.g. for another form, is kept.
+func (s *Service) DiscardSavedDraft(savedAt time.Time) error {
+	return s.drafts.ClearSaved(savedAt)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/duplicate"
+	"github.com/bgics/pmjay-go/history"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// FindDuplicates returns the pairs of records that are likely the same
+// patient, best match first.
+func (s *Service) FindDuplicates(user auth.User) ([]duplicate.Pair, error) {
+	if err := user.Require(auth.PermDelete); err != nil {
+		return nil, err
+	}
+
+	records, err := s.store.GetRecordsByName("")
+	if err != nil {
+		return nil, err
+	}
+
+	return duplicate.Find(records, duplicate.MinScore), nil
+}
+
+// MergeRecords saves merged, the record kept with the fields picked from both
+// duplicates, and moves retired to the trash pointing to it. Both records
+// get a merge revision.
+func (s *Service) MergeRecords(user auth.User, merged, retired model.FormData) (model.FormData, error) {
+	err := user.Require(auth.PermDelete)
+	if err == nil && merged.ID == retired.ID {
+		err = fmt.Errorf("cannot merge %s into itself", merged.Name)
+	}
+
+	var record model.FormData
+	if err == nil {
+		note := fmt.Sprintf("merged %s (%s) into this record", retired.Name, retired.ID)
+		record, err = s.saveRecord(user, merged, history.ActionMerge, note)
+	}
+	if err == nil {
+		err = s.store.RetireRecord(retired.ID, record.ID, user.Name)
+	}
+	if err == nil {
+		retired.MergedInto = record.ID
+		note := fmt.Sprintf("merged into %s (%s)", record.Name, record.ID)
+		err = s.appendRevision(user, history.ActionMerge, retired, nil, note)
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionMerge, retired, retired, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return record, err
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"io"
+	"time"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/hbp"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// SearchPackages returns at most limit packages of the master matching
+// query.
+func (s *Service) SearchPackages(query string, limit int) ([]hbp.Package, error) {
+	return s.packages.Search(query, limit)
+}
+
+// LookupPackage returns the package with code from the master.
+func (s *Service) LookupPackage(code string) (hbp.Package, bool, error) {
+	return s.packages.Lookup(code)
+}
+
+// ImportPackages replaces the package master with the CSV read from r and
+// returns how many packages it has.
+func (s *Service) ImportPackages(user auth.User, r io.Reader) (int, error) {
+	err := user.Require(auth.PermManageData)
+
+	var n int
+	if err == nil {
+		n, err = s.packages.Import(r)
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionImportPackages, model.FormData{}, model.FormData{}, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return n, err
+}
+
+// PackageClaims returns the records with packages admitted between from and
+// to, either of which may be zero to leave that end open.
+func (s *Service) PackageClaims(user auth.User, from, to time.Time) ([]model.FormData, error) {
+	if err := user.Require(auth.PermViewAudit); err != nil {
+		return nil, err
+	}
+
+	records, err := s.store.GetRecordsByName("")
+	if err != nil {
+		return nil, err
+	}
+
+	var claims []model.FormData
+	for _, record := range records {
+		if len(record.Packages) == 0 {
+			continue
+		}
+		if !from.IsZero() && record.DateOfAdmission.Before(from) {
+			continue
+		}
+		if !to.IsZero() && record.DateOfAdmission.After(to) {
+			continue
+		}
+		claims = append(claims, record)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/backup"
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
+	"github.com/bgics/pmjay-go/draft"
+	"github.com/bgics/pmjay-go/hbp"
+	"github.com/bgics/pmjay-go/history"
+	"github.com/bgics/pmjay-go/icd"
+	"github.com/bgics/pmjay-go/model"
+	"github.com/bgics/pmjay-go/pdf"
+	"github.com/bgics/pmjay-go/pincode"
+	"github.com/bgics/pmjay-go/store"
+	"github.com/bgics/pmjay-go/validate"
+)
+
+// Service sits between the views and the store. It checks the permissions of
+// the acting user and writes the audit log for every change.
+type Service struct {
+	store    *store.Store
+	audit    *audit.Log
+	history  *history.Log
+	backups  *backup.Manager
+	drafts   *draft.Store
+	icd      *icd.Catalogue
+	packages *hbp.Master
+	pincodes *pincode.Directory
+}
+
+// NewService returns a service on s. The data file is backed up with b
+// before it is overwritten.
+func NewService(s *store.Store, a *audit.Log, h *history.Log, b *backup.Manager, d *draft.Store, c *icd.Catalogue, p *hbp.Master, pd *pincode.Directory) *Service {
+	s.SetBeforeWrite(b.BeforeWrite)
+
+	return &Service{
+		store:    s,
+		audit:    a,
+		history:  h,
+		backups:  b,
+		drafts:   d,
+		icd:      c,
+		packages: p,
+		pincodes: pd,
+	}
+}
+
+// NewDefaultService returns a service on the data and log files named in
+// config.
+func NewDefaultService() *Service {
+	return NewService(
+		store.NewStore(),
+		audit.NewLog(config.App.AuditFile()),
+		history.NewLog(config.App.HistoryFile()),
+		&backup.Manager{
+			Source:   config.App.DataFile(),
+			Dir:      config.App.BackupDir(),
+			Keep:     config.App.Backup.Generations,
+			Schedule: config.App.Backup.Schedule,
+		},
+		draft.NewStore(config.App.DraftFile()),
+		loadCatalogue(),
+		hbp.NewMaster(config.App.HBPFile()),
+		pincode.NewDirectory(config.App.PincodeFile()),
+	)
+}
+
+func loadCatalogue() *icd.Catalogue {
+	c, err := icd.Load(config.App.AssetsDir)
+	if err != nil {
+		slog.Warn("cannot load the full ICD-10 catalogue", "err", err)
+	}
+	return c
+}
+
+// SetKey sets the key used for the data file and the logs.
+func (s *Service) SetKey(key *crypt.Key) {
+	s.store.SetKey(key)
+	s.audit.SetKey(key)
+	s.history.SetKey(key)
+	s.drafts.SetKey(key)
+}
+
+// EncryptData sets up encryption with passphrase and rewrites the data file
+// and the logs encrypted. The backups taken so far are encrypted in place and
+// no new backup is taken, as it would keep the plaintext.
+func (s *Service) EncryptData(user auth.User, keyFileStr, passphrase string) error {
+	if err := user.Require(auth.PermManageData); err != nil {
+		return err
+	}
+
+	key, err := crypt.NewKeyFile(keyFileStr, passphrase)
+	if err != nil {
+		return err
+	}
+	s.SetKey(key)
+
+	if err := s.store.Rewrite(); err != nil {
+		return err
+	}
+	if err := s.history.Rewrite(); err != nil {
+		return fmt.Errorf("cannot encrypt history: %w", err)
+	}
+	if err := s.audit.Rewrite(); err != nil {
+		return fmt.Errorf("cannot encrypt audit log: %w", err)
+	}
+	if err := s.drafts.Rewrite(); err != nil {
+		return fmt.Errorf("cannot encrypt draft: %w", err)
+	}
+	if err := s.backups.Rewrite(func(data []byte) []byte {
+		if crypt.IsEncryptedFile(data) {
+			return data
+		}
+		return key.EncodeFile(data)
+	}); err != nil {
+		return fmt.Errorf("cannot encrypt backups: %w", err)
+	}
+	removeOutput()
+
+	return s.appendAudit(user, audit.ActionEncrypt, model.FormData{}, model.FormData{}, nil, nil)
+}
+
+// ExportRecords writes all records as plaintext CSV to w.
+func (s *Service) ExportRecords(user auth.User, w io.Writer, includeDeleted bool) error {
+	err := user.Require(auth.PermManageData)
+	if err == nil {
+		err = s.store.ExportCSV(w, includeDeleted)
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionExport, model.FormData{}, model.FormData{}, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+// SearchRecords returns the records whose name has name in it, with the
+// siblings of a multiple birth next to each other.
+func (s *Service) SearchRecords(user auth.User, name string) ([]model.FormData, error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return nil, err
+	}
+
+	records, err := s.store.GetRecordsByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return model.GroupSiblings(records), nil
+}
+
+func (s *Service) SaveRecord(user auth.User, fd model.FormData) (model.FormData, error) {
+	record, err := s.saveRecord(user, fd, "", "")
+	if auditErr := s.appendAudit(user, audit.ActionSave, fd, record, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return record, err
+}
+
+// PrintRecord saves fd and prints numDays pages of it, one per day starting
+// at fd.Date.
+func (s *Service) PrintRecord(ctx context.Context, user auth.User, fd model.FormData, numDays int) (model.FormData, error) {
+	if err := user.Require(auth.PermPrint); err != nil {
+		return model.FormData{}, err
+	}
+
+	record, err := s.SaveRecord(user, fd)
+	if err != nil {
+		return model.FormData{}, err
+	}
+
+	printErr := pdf.GeneratePDF(config.App.OutputFile(), record, numDays)
+	if printErr == nil {
+		printErr = pdf.PrintPDF(ctx, config.App.OutputFile())
+	}
+	removeOutput()
+	err = printErr
+
+	// the sheet is out by now, so a failure to note the first print does not
+	// make the print itself fail in the audit log
+	if printErr == nil && record.FirstPrintedAt.IsZero() {
+		printed := record
+		printed.FirstPrintedAt = time.Now()
+		if printed, err = s.saveRecord(user, printed, history.ActionPrint, "first printed"); err != nil {
+			err = fmt.Errorf("printed, but cannot note the first print: %w", err)
+		} else {
+			record = printed
+		}
+	}
+
+	var dates []string
+	for i := range numDays {
+		dates = append(dates, fd.Date.AddDate(0, 0, i).Format(config.StorageDateFormat))
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionPrint, fd, record, dates, printErr); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return record, err
+}
+
+// removeOutput removes the generated sheet, as it holds the patient's
+// details in plaintext.
+func removeOutput() {
+	if err := os.Remove(config.App.OutputFile()); err != nil && !os.IsNotExist(err) {
+		slog.Warn("cannot remove printed sheet", "err", err)
+	}
+}
+
+func (s *Service) DeleteRecord(user auth.User, record model.FormData) error {
+	err := user.Require(auth.PermDelete)
+	if err == nil {
+		err = s.store.RemoveRecord(record.ID, user.Name)
+	}
+	if err == nil {
+		err = s.appendRevision(user, history.ActionDelete, record, nil, "")
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionDelete, record, record, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+func (s *Service) RestoreRecord(user auth.User, record model.FormData) error {
+	err := user.Require(auth.PermDelete)
+	if err == nil {
+		err = s.store.RestoreRecord(record.ID)
+	}
+	if err == nil {
+		err = s.appendRevision(user, history.ActionRestore, record, nil, "")
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionRestore, record, record, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+func (s *Service) PurgeRecord(user auth.User, record model.FormData) error {
+	err := user.Require(auth.PermDelete)
+	if err == nil {
+		err = s.store.PurgeRecord(record.ID)
+	}
+	if err == nil {
+		err = s.appendRevision(user, history.ActionPurge, record, nil, "")
+	}
+
+	if auditErr := s.appendAudit(user, audit.ActionPurge, record, record, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+// DeletedRecords lists the trash, most recently deleted first.
+func (s *Service) DeletedRecords(user auth.User) ([]model.FormData, error) {
+	if err := user.Require(auth.PermDelete); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetDeletedRecords()
+}
+
+// RecordHistory returns the revisions of a record, newest first.
+func (s *Service) RecordHistory(user auth.User, record model.FormData) ([]history.Revision, error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.history.Revisions(record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read history: %w", err)
+	}
+
+	slices.Reverse(revisions)
+
+	return revisions, nil
+}
+
+// RevertRecord sets the fields of a record back to how they were after the
+// given revision. The revert is saved as a new revision.
+func (s *Service) RevertRecord(user auth.User, rev history.Revision) (model.FormData, error) {
+	fd := rev.Record
+	fd.ID = rev.RecordID
+
+	record, err := s.saveRecord(user, fd, history.ActionRevert, fmt.Sprintf("reverted to revision %d", rev.Number))
+	if auditErr := s.appendAudit(user, audit.ActionRevert, fd, record, nil, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+
+	return record, err
+}
+
+func (s *Service) QueryAudit(user auth.User, f audit.Filter) ([]audit.Entry, error) {
+	if err := user.Require(auth.PermViewAudit); err != nil {
+		return nil, err
+	}
+
+	return s.audit.Query(f, config.StorageDateFormat)
+}
+
+// saveRecord checks and saves fd and adds a revision for it, as action with
+// note. An empty action is a create or update, whose revision is left out
+// when nothing changed.
+func (s *Service) saveRecord(user auth.User, fd model.FormData, action, note string) (model.FormData, error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return model.FormData{}, err
+	}
+
+	if err := validate.Record(fd).Err(); err != nil {
+		return model.FormData{}, err
+	}
+
+	existing, found, err := s.store.MatchRecord(fd)
+	if err != nil {
+		return model.FormData{}, err
+	}
+
+	if found {
+		if err := checkEditAfterPrint(user, existing, fd); err != nil {
+			return model.FormData{}, err
+		}
+		// only the first print sets when the record was first printed
+		if action != history.ActionPrint {
+			fd.FirstPrintedAt = existing.FirstPrintedAt
+		}
+		fd.DeletedBy = existing.DeletedBy
+		fd.DeletedAt = existing.DeletedAt
+	}
+
+	fd.UpdatedBy = user.Name
+	fd.UpdatedAt = time.Now()
+
+	record, err := s.store.AddRecord(fd)
+	if err != nil {
+		return model.FormData{}, err
+	}
+
+	changes := history.Diff(existing, record, config.StorageDateFormat)
+	switch {
+	case action != "":
+	case !found:
+		action = history.ActionCreate
+	case len(changes) > 0:
+		action = history.ActionUpdate
+	}
+
+	if action != "" {
+		if err := s.appendRevision(user, action, record, changes, note); err != nil {
+			return record, err
+		}
+	}
+
+	if !found && record.SiblingGroup != "" {
+		return record, s.startSiblingGroup(user, record.SiblingGroup)
+	}
+	return record, nil
+}
+
+func (s *Service) appendRevision(user auth.User, action string, record model.FormData, changes []history.Change, note string) error {
+	_, err := s.history.Append(history.Revision{
+		RecordID: record.ID,
+		Operator: user.Name,
+		Action:   action,
+		Changes:  changes,
+		Record:   record,
+		Note:     note,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot write history: %w", err)
+	}
+
+	return nil
+}
+
+// checkEditAfterPrint stops fields that are already on a printed sheet from
+// being changed by users without PermEditAfterPrint.
+func checkEditAfterPrint(user auth.User, existing, fd model.FormData) error {
+	if existing.FirstPrintedAt.IsZero() || user.Can(auth.PermEditAfterPrint) {
+		return nil
+	}
+
+	if !sameDay(existing.DateOfBirth, fd.DateOfBirth) {
+		return fmt.Errorf("%w: date of birth cannot be changed after the first print", auth.ErrPermissionDenied)
+	}
+
+	return nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format(config.StorageDateFormat) == b.Format(config.StorageDateFormat)
+}
+
+// appendAudit records action on the audit log. record is the stored record,
+// which is empty when saving failed, in which case fd identifies the patient.
+func (s *Service) appendAudit(user auth.User, action string, fd, record model.FormData, dates []string, err error) error {
+	if record.ID == "" {
+		record = fd
+	}
+
+	entry := audit.Entry{
+		Operator:    user.Name,
+		Action:      action,
+		PatientID:   record.ID,
+		PatientName: record.Name,
+		Outcome:     audit.Outcome(err),
+	}
+
+	if action == audit.ActionPrint {
+		entry.Template = config.App.Template
+		entry.Dates = dates
+		entry.Printer = config.App.Print.Command
+	}
+
+	logAction(entry, err)
+
+	if err := s.audit.Append(entry); err != nil {
+		return fmt.Errorf("cannot write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// logAction logs an audited action along with the error it failed with.
+func logAction(entry audit.Entry, err error) {
+	attrs := []any{"action", entry.Action, "user", entry.Operator}
+	if entry.PatientID != "" {
+		attrs = append(attrs, "patient_id", entry.PatientID)
+	}
+	if entry.Backup != "" {
+		attrs = append(attrs, "backup", entry.Backup)
+	}
+	if len(entry.Dates) > 0 {
+		attrs = append(attrs, "dates", entry.Dates)
+	}
+
+	if err != nil {
+		slog.Error("action failed", append(attrs, "err", err)...)
+		return
+	}
+	slog.Info("action done", attrs...)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/bgics/pmjay-go/audit"
+	"github.com/bgics/pmjay-go/auth"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// NewSibling returns a new record for the next born of the multiple birth
+// of record, to be filled in and saved. record itself joins the group as the
+// first born once the sibling is saved.
+func (s *Service) NewSibling(user auth.User, record model.FormData) (model.FormData, error) {
+	if err := user.Require(auth.PermSave); err != nil {
+		return model.FormData{}, err
+	}
+	if record.ID == "" {
+		return model.FormData{}, fmt.Errorf("save %s before adding a sibling", record.Name)
+	}
+
+	order := 2
+	if record.SiblingGroup != "" {
+		siblings, err := s.store.GetSiblings(record.SiblingGroup)
+		if err != nil {
+			return model.FormData{}, err
+		}
+		for _, sibling := range siblings {
+			order = max(order, sibling.BirthOrder+1)
+		}
+	}
+
+	return record.Sibling(order), nil
+}
+
+// startSiblingGroup makes the record with ID group the first born of its
+// group when its first sibling is saved, numbering its name to match.
+func (s *Service) startSiblingGroup(user auth.User, group string) error {
+	siblings, err := s.store.GetSiblings(group)
+	if err != nil {
+		return err
+	}
+
+	for _, first := range siblings {
+		if first.ID != group || first.SiblingGroup != "" {
+			continue
+		}
+
+		first.SiblingGroup = group
+		first.BirthOrder = 1
+		first.Name = model.BirthOrderName(first.Name, 1)
+		record, err := s.saveRecord(user, first, "", "")
+		if auditErr := s.appendAudit(user, audit.ActionSave, first, record, nil, err); auditErr != nil && err == nil {
+			err = auditErr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot add %s to the siblings: %w", first.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -1,65 +1,281 @@
 package store
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/crypt"
 	"github.com/bgics/pmjay-go/model"
 )
 
-// TODO: there no strict enforcing of the ordering of fields in csv
 // TODO: currently this module assumes that the data is generated only by this program
 // external data could be invalid and cause error
 
 var (
-	CSVHeader = []string{"Name", "Address", "Diagnosis", "Gender", "Date", "Date of Admission", "Date of Birth"}
+	CSVHeader = []string{"ID", "Name", "Address", "Diagnosis", "Gender", "Date", "Date of Admission", "Date of Birth", "Updated By", "Updated At", "First Printed At", "Deleted By", "Deleted At", "Diagnosis Code", "Packages", "House/Village", "Block", "District", "State", "PIN", "Sibling Group", "Birth Order", "Merged Into"}
 )
 
 const (
-	nameIndex = iota
+	idIndex = iota
+	nameIndex
 	addressIndex
 	diagnosisIndex
 	genderIndex
 	dateIndex
 	doaIndex
 	dobIndex
+	updatedByIndex
+	updatedAtIndex
+	firstPrintedAtIndex
+	deletedByIndex
+	deletedAtIndex
+	diagnosisCodeIndex
+	packagesIndex
+	lineIndex
+	blockIndex
+	districtIndex
+	stateIndex
+	pinIndex
+	siblingGroupIndex
+	birthOrderIndex
+	mergedIntoIndex
 )
 
+// maxLiveRecords is how many records outside the trash are kept. Records in
+// the trash do not count, so they stay until restored or purged.
+const maxLiveRecords = 10
+
+// optionalColumns were added after the first file format and may be missing
+// from older files.
+var optionalColumns = []int{idIndex, updatedByIndex, updatedAtIndex, firstPrintedAtIndex, deletedByIndex, deletedAtIndex, diagnosisCodeIndex, packagesIndex, lineIndex, blockIndex, districtIndex, stateIndex, pinIndex, siblingGroupIndex, birthOrderIndex, mergedIntoIndex}
+
+// Store is safe for use from the UI and background jobs at the same time.
 type Store struct {
+	mu      sync.Mutex
 	records []model.FormData
 	isValid bool
+	key     *crypt.Key
+
+	beforeWrite func() error
 }
 
 func NewStore() *Store {
 	return &Store{}
 }
 
-func (s *Store) AddRecord(fd model.FormData) error {
+// SetKey sets the key the data file is encrypted with. Records are reloaded
+// with it on next access.
+func (s *Store) SetKey(key *crypt.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = key
+	s.isValid = false
+}
+
+// SetBeforeWrite sets a function that is called before the data file is
+// overwritten, e.g. to back it up. An error from it stops the write.
+func (s *Store) SetBeforeWrite(beforeWrite func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.beforeWrite = beforeWrite
+}
+
+// Reload drops the records in memory, they are read from disk on next access.
+func (s *Store) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.isValid = false
+}
+
+// ReadRecordsFile reads the records of a data file other than the store's
+// own, such as a backup.
+func (s *Store) ReadRecordsFile(fileStr string) ([]model.FormData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(fileStr)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decodeRecords(data)
+}
+
+// IsEncrypted reports whether the data file on disk is encrypted.
+func IsEncrypted() (bool, error) {
+	data, err := os.ReadFile(config.App.DataFile())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return crypt.IsEncryptedFile(data), nil
+}
+
+// Rewrite loads the records and writes them back, e.g. with a new key. The
+// data file is not backed up first, as the backup would keep the old
+// plaintext.
+func (s *Store) Rewrite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadRecords(); err != nil {
+		return fmt.Errorf("cannot load records: %w", err)
+	}
+
+	if err := s.writeRecords(); err != nil {
+		s.isValid = false
+		return fmt.Errorf("cannot save records: %w", err)
+	}
+
+	return nil
+}
+
+// ExportCSV writes the records as plaintext CSV to w.
+func (s *Store) ExportCSV(w io.Writer, includeDeleted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.isValid {
 		if err := s.loadRecords(); err != nil {
 			return fmt.Errorf("cannot load records: %w", err)
 		}
 	}
 
-	index := s.getRecordIndex(fd.Name)
+	var records []model.FormData
+	for _, record := range s.records {
+		if includeDeleted || !record.IsDeleted() {
+			records = append(records, record)
+		}
+	}
+
+	return writeCSV(w, records)
+}
+
+// AddRecord saves fd and returns the stored record. A record with the same ID
+// is replaced; a record without an ID replaces one with the same name, and a
+// new ID is assigned if none exists yet.
+func (s *Store) AddRecord(fd model.FormData) (model.FormData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isValid {
+		if err := s.loadRecords(); err != nil {
+			return model.FormData{}, fmt.Errorf("cannot load records: %w", err)
+		}
+	}
+
+	index := s.matchRecordIndex(fd)
 
 	if index != -1 {
+		fd.ID = s.records[index].ID
 		s.records[index] = fd
 	} else {
-		if len(s.records) >= 10 {
-			s.records = s.records[:9]
+		if fd.ID == "" {
+			fd.ID = NewID()
 		}
 
+		s.dropOldestLive()
 		s.records = append(s.records, fd)
 	}
 	s.sortRecords()
 
+	if err := s.storeRecords(); err != nil {
+		s.isValid = false
+		return model.FormData{}, fmt.Errorf("cannot save records: %w", err)
+	}
+
+	return fd, nil
+}
+
+// MatchRecord returns the stored record that AddRecord would replace with fd.
+func (s *Store) MatchRecord(fd model.FormData) (model.FormData, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isValid {
+		if err := s.loadRecords(); err != nil {
+			return model.FormData{}, false, fmt.Errorf("cannot load records: %w", err)
+		}
+	}
+
+	index := s.matchRecordIndex(fd)
+	if index == -1 {
+		return model.FormData{}, false, nil
+	}
+
+	return s.records[index], true, nil
+}
+
+// RemoveRecord marks the record as deleted by the given operator. It stays in
+// the store, hidden from search, until it is restored or purged.
+func (s *Store) RemoveRecord(id, deletedBy string) error {
+	return s.updateRecord(id, func(record *model.FormData) {
+		record.DeletedBy = deletedBy
+		record.DeletedAt = time.Now()
+	})
+}
+
+func (s *Store) RestoreRecord(id string) error {
+	return s.updateRecord(id, func(record *model.FormData) {
+		record.DeletedBy = ""
+		record.DeletedAt = time.Time{}
+		record.MergedInto = ""
+	})
+}
+
+// RetireRecord deletes a duplicate as RemoveRecord does, noting the record it
+// was merged into.
+func (s *Store) RetireRecord(id, mergedInto, deletedBy string) error {
+	return s.updateRecord(id, func(record *model.FormData) {
+		record.DeletedBy = deletedBy
+		record.DeletedAt = time.Now()
+		record.MergedInto = mergedInto
+	})
+}
+
+// PurgeRecord drops a deleted record from the store for good.
+func (s *Store) PurgeRecord(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isValid {
+		if err := s.loadRecords(); err != nil {
+			return fmt.Errorf("cannot load records: %w", err)
+		}
+	}
+
+	index := s.getRecordIndexByID(id)
+
+	if index == -1 {
+		return fmt.Errorf("record not found")
+	}
+	if !s.records[index].IsDeleted() {
+		return fmt.Errorf("only deleted records can be purged")
+	}
+
+	s.records = append(s.records[:index], s.records[index+1:]...)
+
 	if err := s.storeRecords(); err != nil {
 		s.isValid = false
 		return fmt.Errorf("cannot save records: %w", err)
@@ -68,20 +284,73 @@ func (s *Store) AddRecord(fd model.FormData) error {
 	return nil
 }
 
-func (s *Store) RemoveRecord(name string) error {
+func (s *Store) GetDeletedRecords() ([]model.FormData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isValid {
+		if err := s.loadRecords(); err != nil {
+			return nil, fmt.Errorf("cannot load records: %w", err)
+		}
+	}
+
+	var output []model.FormData
+	for _, record := range s.records {
+		if record.IsDeleted() {
+			output = append(output, record)
+		}
+	}
+
+	slices.SortStableFunc(output, func(a, b model.FormData) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+
+	return output, nil
+}
+
+// GetSiblings returns the records of the multiple birth group, including the
+// one that started it, in birth order.
+func (s *Store) GetSiblings(group string) ([]model.FormData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isValid {
+		if err := s.loadRecords(); err != nil {
+			return nil, fmt.Errorf("cannot load records: %w", err)
+		}
+	}
+
+	var output []model.FormData
+	for _, record := range s.records {
+		if !record.IsDeleted() && (record.SiblingGroup == group || record.ID == group) {
+			output = append(output, record)
+		}
+	}
+
+	slices.SortStableFunc(output, func(a, b model.FormData) int {
+		return a.BirthOrder - b.BirthOrder
+	})
+
+	return output, nil
+}
+
+func (s *Store) updateRecord(id string, update func(record *model.FormData)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.isValid {
 		if err := s.loadRecords(); err != nil {
 			return fmt.Errorf("cannot load records: %w", err)
 		}
 	}
 
-	index := s.getRecordIndex(name)
+	index := s.getRecordIndexByID(id)
 
 	if index == -1 {
-		return fmt.Errorf("name not found")
+		return fmt.Errorf("record not found")
 	}
 
-	s.records = append(s.records[:index], s.records[index+1:]...)
+	update(&s.records[index])
 
 	if err := s.storeRecords(); err != nil {
 		s.isValid = false
@@ -92,6 +361,9 @@ func (s *Store) RemoveRecord(name string) error {
 }
 
 func (s *Store) GetRecordsByName(name string) ([]model.FormData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.isValid {
 		if err := s.loadRecords(); err != nil {
 			return nil, fmt.Errorf("cannot load records: %w", err)
@@ -100,6 +372,9 @@ func (s *Store) GetRecordsByName(name string) ([]model.FormData, error) {
 
 	var output []model.FormData
 	for _, record := range s.records {
+		if record.IsDeleted() {
+			continue
+		}
 		if strings.Contains(sanitizeString(record.Name), sanitizeString(name)) {
 			output = append(output, record)
 		}
@@ -109,25 +384,39 @@ func (s *Store) GetRecordsByName(name string) ([]model.FormData, error) {
 }
 
 func (s *Store) storeRecords() error {
-	file, err := os.Create("data.csv")
-	if err != nil {
+	if s.beforeWrite != nil {
+		if err := s.beforeWrite(); err != nil {
+			return fmt.Errorf("cannot back up data: %w", err)
+		}
+	}
+
+	return s.writeRecords()
+}
+
+// writeRecords writes the records to the data file without backing it up.
+func (s *Store) writeRecords() error {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, s.records); err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Printf("error closing file: %v", err)
-		}
-	}()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	if err := os.WriteFile(config.App.DataFile(), s.key.EncodeFile(buf.Bytes()), 0o600); err != nil {
+		return err
+	}
+
+	slog.Debug("records written", "count", len(s.records), "encrypted", s.key != nil)
+	return nil
+}
+
+func writeCSV(w io.Writer, records []model.FormData) error {
+	writer := csv.NewWriter(w)
 
-	err = writer.Write(CSVHeader)
+	err := writer.Write(CSVHeader)
 	if err != nil {
 		return err
 	}
 
-	data := recordsToRows(s.records)
+	data := recordsToRows(records)
 	for _, record := range data {
 		err = writer.Write(record)
 		if err != nil {
@@ -135,11 +424,12 @@ func (s *Store) storeRecords() error {
 		}
 	}
 
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
 
 func (s *Store) loadRecords() error {
-	file, err := os.Open("data.csv")
+	data, err := os.ReadFile(config.App.DataFile())
 	if os.IsNotExist(err) {
 		s.isValid = true
 		return nil
@@ -147,35 +437,46 @@ func (s *Store) loadRecords() error {
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Printf("error closing file: %v", err)
-		}
-	}()
-
-	reader := csv.NewReader(file)
-
-	rows, err := reader.ReadAll()
-	if err != nil {
-		return err
-	}
 
-	s.records, err = rowsToRecords(rows[1:])
+	s.records, err = s.decodeRecords(data)
 	if err != nil {
 		return err
 	}
 
 	s.sortRecords()
+	slog.Debug("records loaded", "count", len(s.records))
 
 	s.isValid = true
 	return nil
 }
 
-func (s *Store) getRecordIndex(name string) int {
+func (s *Store) decodeRecords(data []byte) ([]model.FormData, error) {
+	data, err := s.key.DecodeFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rowsToRecords(rows[0], rows[1:])
+}
+
+// getRecordIndex finds the record named name, telling siblings of a multiple
+// birth apart by their birth order should they have the same name.
+func (s *Store) getRecordIndex(name string, birthOrder int) int {
 	index := -1
 
 	for i, record := range s.records {
-		if sanitizeString(record.Name) == sanitizeString(name) {
+		if !record.IsDeleted() && sanitizeString(record.Name) == sanitizeString(name) && record.BirthOrder == birthOrder {
 			return i
 		}
 	}
@@ -183,10 +484,66 @@ func (s *Store) getRecordIndex(name string) int {
 	return index
 }
 
+func (s *Store) matchRecordIndex(fd model.FormData) int {
+	if fd.ID != "" {
+		return s.getRecordIndexByID(fd.ID)
+	}
+	return s.getRecordIndex(fd.Name, fd.BirthOrder)
+}
+
+func (s *Store) getRecordIndexByID(id string) int {
+	for i, record := range s.records {
+		if record.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// NewID returns a random identifier for a new record.
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// legacyID is the ID of a row written before records had IDs. It is taken
+// from the content of the row, so the row gets the same ID on every load
+// and in every backup until it is written back with it. seen counts the
+// rows with the same content, which are told apart by their order.
+func legacyID(row []string, seen map[string]int) string {
+	content := strings.Join(row, "\x1f")
+	n := seen[content]
+	seen[content]++
+
+	sum := sha256.Sum256([]byte(content + "\x1e" + strconv.Itoa(n)))
+	return hex.EncodeToString(sum[:8])
+}
+
 func sanitizeString(str string) string {
 	return strings.TrimSpace(strings.ToLower(str))
 }
 
+// dropOldestLive drops the oldest record outside the trash if there are
+// maxLiveRecords of them, to make room for one more.
+func (s *Store) dropOldestLive() {
+	live := 0
+	oldest := -1
+	for i, record := range s.records {
+		if !record.IsDeleted() {
+			live++
+			oldest = i
+		}
+	}
+
+	if live >= maxLiveRecords {
+		s.records = slices.Delete(s.records, oldest, oldest+1)
+	}
+}
+
 func (s *Store) sortRecords() {
 	slices.SortStableFunc(s.records, func(a, b model.FormData) int {
 		return b.Date.Compare(a.Date)
@@ -197,13 +554,29 @@ func recordsToRows(records []model.FormData) [][]string {
 	var output [][]string
 	for _, record := range records {
 		fields := []string{
+			record.ID,
 			record.Name,
 			record.Address,
 			record.Diagnosis,
 			string(record.Gender),
-			record.Date.Format(config.DateFormat),
-			record.DateOfAdmission.Format(config.DateFormat),
-			record.DateOfBirth.Format(config.DateFormat),
+			record.Date.Format(config.StorageDateFormat),
+			record.DateOfAdmission.Format(config.StorageDateFormat),
+			record.DateOfBirth.Format(config.StorageDateFormat),
+			record.UpdatedBy,
+			formatOptionalTime(record.UpdatedAt),
+			formatOptionalTime(record.FirstPrintedAt),
+			record.DeletedBy,
+			formatOptionalTime(record.DeletedAt),
+			record.DiagnosisCode,
+			formatPackages(record.Packages),
+			record.AddressParts.Line,
+			record.AddressParts.Block,
+			record.AddressParts.District,
+			record.AddressParts.State,
+			record.AddressParts.PIN,
+			record.SiblingGroup,
+			formatBirthOrder(record.BirthOrder),
+			record.MergedInto,
 		}
 
 		output = append(output, fields)
@@ -211,36 +584,175 @@ func recordsToRows(records []model.FormData) [][]string {
 	return output
 }
 
-func rowsToRecords(rows [][]string) ([]model.FormData, error) {
+// columnIndexes maps the columns of CSVHeader to their position in header, so
+// files written before a column was added can still be read. Missing columns
+// map to -1.
+func columnIndexes(header []string) []int {
+	indexes := make([]int, len(CSVHeader))
+	for i, name := range CSVHeader {
+		indexes[i] = slices.Index(header, name)
+	}
+	return indexes
+}
+
+func rowsToRecords(header []string, rows [][]string) ([]model.FormData, error) {
 	var output []model.FormData
 
+	cols := columnIndexes(header)
+	for i, col := range cols {
+		if col == -1 && !slices.Contains(optionalColumns, i) {
+			return nil, fmt.Errorf("missing column %q", CSVHeader[i])
+		}
+	}
+
+	field := func(row []string, index int) string {
+		if cols[index] == -1 || cols[index] >= len(row) {
+			return ""
+		}
+		return row[cols[index]]
+	}
+
+	legacyIDs := make(map[string]int)
+
 	for _, row := range rows {
-		date, err := time.Parse(config.DateFormat, row[dateIndex])
+		date, err := time.Parse(config.StorageDateFormat, field(row, dateIndex))
 		if err != nil {
 			return nil, err
 		}
 
-		dateOfAdmission, err := time.Parse(config.DateFormat, row[doaIndex])
+		dateOfAdmission, err := time.Parse(config.StorageDateFormat, field(row, doaIndex))
 		if err != nil {
 			return nil, err
 		}
 
-		dateOfBirth, err := time.Parse(config.DateFormat, row[dobIndex])
+		dateOfBirth, err := time.Parse(config.StorageDateFormat, field(row, dobIndex))
 		if err != nil {
 			return nil, err
 		}
 
+		updatedAt, err := parseOptionalTime(field(row, updatedAtIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		firstPrintedAt, err := parseOptionalTime(field(row, firstPrintedAtIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		deletedAt, err := parseOptionalTime(field(row, deletedAtIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		packages, err := parsePackages(field(row, packagesIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		birthOrder := 0
+		if str := field(row, birthOrderIndex); str != "" {
+			if birthOrder, err = strconv.Atoi(str); err != nil {
+				return nil, fmt.Errorf("invalid birth order %q: %w", str, err)
+			}
+		}
+
+		// older records may have the gender written out, they are written
+		// back as the code. One that cannot be read is kept as it is rather
+		// than failing the whole file.
+		gender, err := model.ParseGender(field(row, genderIndex))
+		if err != nil {
+			slog.Warn("unknown gender kept as is", "id", field(row, idIndex), "gender", field(row, genderIndex))
+			gender = model.Gender(strings.TrimSpace(field(row, genderIndex)))
+		}
+
 		record := model.FormData{
-			Name:            row[nameIndex],
-			Address:         row[addressIndex],
-			Diagnosis:       row[diagnosisIndex],
-			Gender:          model.Gender(row[genderIndex]),
+			ID:      field(row, idIndex),
+			Name:    field(row, nameIndex),
+			Address: field(row, addressIndex),
+			AddressParts: model.Address{
+				Line:     field(row, lineIndex),
+				Block:    field(row, blockIndex),
+				District: field(row, districtIndex),
+				State:    field(row, stateIndex),
+				PIN:      field(row, pinIndex),
+			},
+			Diagnosis:       field(row, diagnosisIndex),
+			DiagnosisCode:   field(row, diagnosisCodeIndex),
+			Packages:        packages,
+			Gender:          gender,
 			Date:            date,
 			DateOfAdmission: dateOfAdmission,
 			DateOfBirth:     dateOfBirth,
+			SiblingGroup:    field(row, siblingGroupIndex),
+			BirthOrder:      birthOrder,
+			UpdatedBy:       field(row, updatedByIndex),
+			UpdatedAt:       updatedAt,
+			FirstPrintedAt:  firstPrintedAt,
+			DeletedBy:       field(row, deletedByIndex),
+			DeletedAt:       deletedAt,
+			MergedInto:      field(row, mergedIntoIndex),
+		}
+
+		if record.ID == "" {
+			record.ID = legacyID(row, legacyIDs)
 		}
 
 		output = append(output, record)
 	}
 	return output, nil
 }
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// formatBirthOrder leaves the birth order of a single birth empty.
+func formatBirthOrder(order int) string {
+	if order == 0 {
+		return ""
+	}
+	return strconv.Itoa(order)
+}
+
+// formatPackages writes packages as CODE:RATE pairs separated by semicolons.
+func formatPackages(packages []model.Package) string {
+	pairs := make([]string, len(packages))
+	for i, p := range packages {
+		pairs[i] = fmt.Sprintf("%s:%d", p.Code, p.Rate)
+	}
+	return strings.Join(pairs, ";")
+}
+
+func parsePackages(value string) ([]model.Package, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var packages []model.Package
+	for _, pair := range strings.Split(value, ";") {
+		code, rate, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid package %q", pair)
+		}
+
+		p := model.Package{Code: code}
+		var err error
+		if p.Rate, err = strconv.Atoi(rate); err != nil {
+			return nil, fmt.Errorf("invalid rate of package %q", pair)
+		}
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
@@ -0,0 +1,221 @@
+// Package validate checks a record before it is saved or printed, and finds
+// the text that will not fit on the printed form.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bgics/pmjay-go/config"
+	"github.com/bgics/pmjay-go/model"
+)
+
+// Field is a field of the form a problem is about.
+type Field int
+
+const (
+	Name Field = iota
+	Address
+	Diagnosis
+	Date
+	DateOfAdmission
+	DateOfBirth
+	Packages
+	PIN
+)
+
+func (f Field) String() string {
+	switch f {
+	case Name:
+		return "name"
+	case Address:
+		return "address"
+	case Diagnosis:
+		return "diagnosis"
+	case Date:
+		return "date"
+	case DateOfAdmission:
+		return "DOA"
+	case DateOfBirth:
+		return "DOB"
+	case Packages:
+		return "packages"
+	case PIN:
+		return "PIN"
+	}
+	return fmt.Sprintf("Field(%d)", int(f))
+}
+
+type Kind int
+
+const (
+	// KindMissing is a required field left empty
+	KindMissing Kind = iota
+	// KindInvalid is a value that cannot be saved
+	KindInvalid
+	// KindTruncated is text that is cut off on the printed form. It does not
+	// stop the record from being saved or printed.
+	KindTruncated
+)
+
+// Problem is something wrong with one field.
+type Problem struct {
+	Field   Field
+	Kind    Kind
+	Message string
+}
+
+// IsWarning reports whether the record can be saved despite p.
+func (p Problem) IsWarning() bool {
+	return p.Kind == KindTruncated
+}
+
+type Problems []Problem
+
+// For returns the first problem with field, errors before warnings.
+func (ps Problems) For(field Field) (Problem, bool) {
+	var warning *Problem
+	for i, p := range ps {
+		if p.Field != field {
+			continue
+		}
+		if !p.IsWarning() {
+			return p, true
+		}
+		if warning == nil {
+			warning = &ps[i]
+		}
+	}
+
+	if warning != nil {
+		return *warning, true
+	}
+	return Problem{}, false
+}
+
+// Err is an error listing the problems that stop the record from being
+// saved, nil if there are none.
+func (ps Problems) Err() error {
+	var messages []string
+	for _, p := range ps {
+		if !p.IsWarning() {
+			messages = append(messages, fmt.Sprintf("%s %s", p.Field, p.Message))
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// Record returns everything wrong with fd.
+func Record(fd model.FormData) Problems {
+	var ps Problems
+
+	_, addressCut := AddressLines(fd.Address)
+
+	// the printed form cuts text by bytes, see pdf.trim
+	text := []struct {
+		field Field
+		value string
+		over  int
+	}{
+		{Name, fd.Name, len(fd.Name) - maxChars(config.NAME)},
+		{Address, fd.Address, len(addressCut)},
+		{Diagnosis, fd.Diagnosis, len(fd.Diagnosis) - maxChars(config.DIAGNOSIS)},
+	}
+	for _, t := range text {
+		if strings.TrimSpace(t.value) == "" {
+			ps = append(ps, Problem{Field: t.field, Kind: KindMissing, Message: "is required"})
+			continue
+		}
+
+		if over := t.over; over > 0 {
+			ps = append(ps, Problem{
+				Field:   t.field,
+				Kind:    KindTruncated,
+				Message: fmt.Sprintf("is %d characters too long, it will be cut off when printed", over),
+			})
+		}
+	}
+
+	if pin := fd.AddressParts.PIN; pin != "" && (len(pin) != 6 || strings.Trim(pin, "0123456789") != "") {
+		ps = append(ps, Problem{Field: PIN, Kind: KindInvalid, Message: "must be 6 digits"})
+	}
+
+	if over := len(PackagesText(fd.Packages)) - maxChars(config.PACKAGES); len(fd.Packages) > 0 && over > 0 {
+		ps = append(ps, Problem{
+			Field:   Packages,
+			Kind:    KindTruncated,
+			Message: fmt.Sprintf("are %d characters too long, the last ones will be cut off when printed", over),
+		})
+	}
+
+	date := fd.Date.Format(config.App.DateFormat)
+	doa := fd.DateOfAdmission.Format(config.App.DateFormat)
+
+	if fd.DateOfAdmission.After(fd.Date) {
+		ps = append(ps, Problem{Field: DateOfAdmission, Kind: KindInvalid, Message: "is after the sheet date " + date})
+	}
+	if fd.DateOfBirth.After(fd.Date) {
+		ps = append(ps, Problem{Field: DateOfBirth, Kind: KindInvalid, Message: "is after the sheet date " + date})
+	} else if fd.DateOfBirth.After(fd.DateOfAdmission) {
+		ps = append(ps, Problem{Field: DateOfBirth, Kind: KindInvalid, Message: "is after the DOA " + doa})
+	}
+
+	return ps
+}
+
+func maxChars(fields ...config.FieldName) int {
+	total := 0
+	for _, field := range fields {
+		total += config.FieldConfig[field].MaxChars
+	}
+	return total
+}
+
+// PackagesText is how packages are written on the printed form.
+func PackagesText(packages []model.Package) string {
+	codes := make([]string, len(packages))
+	for i, p := range packages {
+		codes[i] = p.Code
+	}
+	return "HBP " + strings.Join(codes, ", ")
+}
+
+// AddressLines lays address out on the address lines of the printed form,
+// breaking lines between words where it can. cut is the end of address that
+// does not fit.
+func AddressLines(address string) (lines []string, cut string) {
+	widths := []int{
+		config.FieldConfig[config.ADDRESS1].MaxChars,
+		config.FieldConfig[config.ADDRESS2].MaxChars,
+		config.FieldConfig[config.ADDRESS3].MaxChars,
+	}
+
+	rest := strings.TrimSpace(address)
+	for _, width := range widths {
+		if rest == "" {
+			break
+		}
+
+		line := rest
+		if len(rest) > width {
+			line = rest[:width]
+			// break before the word that does not fit, unless it is longer
+			// than the line
+			if rest[width] != ' ' {
+				if i := strings.LastIndexByte(line, ' '); i > 0 {
+					line = line[:i]
+				}
+			}
+		}
+
+		lines = append(lines, strings.TrimSpace(line))
+		rest = strings.TrimSpace(rest[len(line):])
+	}
+
+	return lines, rest
+}